@@ -2,21 +2,32 @@ package plaintext
 
 import (
 	"bufio"
+	"database/sql"
 	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"tudo/core/areas"
 	"tudo/core/capture"
+	"tudo/core/checklist"
 	"tudo/core/contexts"
 	"tudo/core/log"
+	"tudo/core/move"
+	"tudo/core/notes"
+	"tudo/core/people"
 	"tudo/core/projects"
+	"tudo/core/reading"
 	"tudo/core/someday"
+	"tudo/core/tags"
 	"tudo/core/tasks"
+	"tudo/core/templates"
 	"tudo/core/waiting"
 	"tudo/database"
 )
@@ -25,6 +36,9 @@ var invalidCommand error = errors.New("Invalid command: ")
 
 var invalidCommandFormat error = errors.New("Invalid command format")
 
+// boolFlags lists the flags that never take a value.
+var boolFlags = []string{"help", "no-context", "json", "cascade", "all", "done", "archived", "to-next", "to-someday", "to-waiting", "overdue", "count"}
+
 func ParseArgs(dbFile string, args []string) {
 	db, err := database.Connect(dbFile)
 	if err != nil {
@@ -33,45 +47,43 @@ func ParseArgs(dbFile string, args []string) {
 	}
 	defer db.Close()
 
+	args, flags, flagValues := parseFlags(args, boolFlags)
+	if _, ok := flags["help"]; ok {
+		fmt.Println("For help, use `tudo help`")
+		return
+	}
+
 	if len(args) == 0 {
 		noTasks := true
+		key := sortKey(flags, "priority", taskSortKeys...)
 
 		calendarTasks, err := tasks.GetTodayCalenderTasks(db)
 		if err != nil {
 			fatalError(err)
 		}
+		calendarTasks = filterTasksByTag(db, flags, calendarTasks)
 		if len(calendarTasks) > 0 {
 			noTasks = false
 		}
+		sortTasks(db, calendarTasks, key)
 		fmt.Println("CALENDAR")
 		for _, t := range calendarTasks {
-			fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
-			if t.ProjectID != nil {
-				project, err := projects.Get(db, *t.ProjectID)
-				if err != nil {
-					fatalError(err)
-				}
-				fmt.Print(fmt.Sprint("Project: ", project.Content, "\n"))
-			}
-			if t.Context != nil {
-				fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
-			}
+			printTask(db, t, true)
 		}
 
 		nextActions, err := tasks.GetActiveNextActions(db)
 		if err != nil {
 			fatalError(err)
 		}
+		nextActions = withoutBlocked(db, filterTasksByTag(db, flags, nextActions))
 		if len(nextActions) > 0 {
 			noTasks = false
 		}
+		sortTasks(db, nextActions, key)
 
 		fmt.Println("\nNEXT ACTIONS")
 		for _, a := range nextActions {
-			fmt.Print(fmt.Sprint("- ID: ", a.ID, "\n", a.Content, "\n"))
-			if a.Context != nil {
-				fmt.Print(fmt.Sprint("Context: ", *a.Context, "\n"))
-			}
+			printTask(db, a, false)
 		}
 
 		projectList, err := projects.GetActive(db)
@@ -85,15 +97,31 @@ func ParseArgs(dbFile string, args []string) {
 			if err != nil {
 				fatalError(err)
 			}
+			if project.Sequential && len(tasks) > 1 {
+				tasks = tasks[:1]
+			}
+			tasks = withoutBlocked(db, filterTasksByTag(db, flags, tasks))
 			if len(tasks) > 0 {
 				noTasks = false
 				fmt.Println(project.Content)
 			}
+			sortTasks(db, tasks, key)
 			for _, t := range tasks {
-				fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
-				if t.Context != nil {
-					fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
-				}
+				printTask(db, t, false)
+			}
+		}
+
+		nudges, err := waiting.GetOverdue(db)
+		if err != nil {
+			fatalError(err)
+		}
+		match := tagMatcher(db, flags, "waiting")
+		nudges = slices.DeleteFunc(nudges, func(w waiting.TudoWaiting) bool { return !match(w.ID) })
+		if len(nudges) > 0 {
+			noTasks = false
+			fmt.Println("\nNEEDS A NUDGE")
+			for _, w := range nudges {
+				printWaiting(w)
 			}
 		}
 		if noTasks {
@@ -104,8 +132,6 @@ func ParseArgs(dbFile string, args []string) {
 
 	switch args[0] {
 	case "-h":
-		fallthrough
-	case "--help":
 		fmt.Println("For help, use `tudo help`")
 	case "help":
 		fmt.Print(`tudo - personal command-line task manager
@@ -117,30 +143,129 @@ Commands:
     help                      Show this help message
     new <type>                Create a new item
         in                    Start a capture session
-        next                  Create a next action
-        project               Create a new project
+        next                  Create a next action (fields can also be given as
+                              --due, --context, --estimate, --energy, --priority
+                              and --person to put it on someone's agenda)
+        project               Create a new project (--order sequential|parallel,
+                              --outcome <text>, --purpose <text>, --area <area>,
+                              --parent <name|id> to make it a sub-project); the
+                              name may follow, e.g. new project --template release
+                              "v2.3" [--start YYYY-MM-DD] creates it from a template
         context               Create a new context
-        wait                  Create a new waiting-for task
-        someday               Create a new someday/maybe item
+        area                  Create a new area of responsibility (--horizon
+                              goal|vision|purpose for higher horizons,
+                              --parent <area> for the one it serves)
+        schedule              Add an availability window to a context
+        check <task id>       Add checklist items to a task
+        person                Add a person to link waiting-for items and tasks to;
+                              --delegate, --person and --to also add a new name
+        wait                  Create a new waiting-for task (--delegate <person>,
+                              --delegated, --expected and --follow-up dates)
+        someday               Create a new someday/maybe item (--category <name>)
+        read                  Add an article, book or document to the reading list
+                              (--title, --url, --source, --time e.g. 20m)
         <project name>        Add a new task under the given project
+                              (every type accepts --tag <tags>, comma separated)
 
     done <type> <id|name>     Mark an item as done
         in <id>               Mark a capture item as done
         task <id>             Mark a task as done
-        waiting <id>          Mark a waiting-for task as done
+        waiting <id>          Mark a waiting-for task as done, offering a follow-up
+                              task in the project and context of a delegated task
+                              (--next <text> creates it without asking)
         someday <id>          Mark a someday item as done
-        <project name>        Mark a project as completed
+        read <id>             Mark a reading list item as read
+        <project name>        Mark a project as completed (--cascade also completes
+                              its open sub-projects, which are refused otherwise);
+                              its open tasks are listed to complete, move to
+                              another project, turn into next actions or cancel
+                              (--tasks complete|move|next|cancel, --to <project>)
+
+    project <action>          Manage projects
+        sequential <name|id>  Only expose the first unfinished task as next action
+        parallel <name|id>    Expose every unfinished task as next action
+        reorder <task id> <n> Move a task to position n within its project
+        rename <name|id>      Rename a project (--to <new name>)
+        reopen <name|id>      Make a finished or archived project active again
+        archive <name|id>     Hide a project from every listing, keeping its tasks
+    agenda <person>           List what we are waiting on from a person and the tasks
+                              to discuss with them
+    people                    List people with their open items
+    delegate task <id>        Turn a task into a waiting-for item, keeping its project
+                              and context (--to <person>, --expected and --follow-up
+                              dates)
+    move <type> <id>          Move a task, someday or waiting item to another list
+                              (--to-project <name|id>, --to-next, --to-someday or
+                              --to-waiting), keeping its notes, tags and history;
+                              a task moved to next actions loses its due date
+    block <id> --by <id>      Mark a task as unable to start before another is done
+    unblock <id> --by <id>    Remove a dependency between two tasks
+    blocked                   List tasks waiting on unfinished tasks; they are hidden
+                              from next, now and the dashboard until unblocked
+    check <item id>           Tick off a checklist item, offering to finish the task
+                              once every item is checked
+    uncheck <item id>         Untick a checklist item
 
     in                        List active capture (in) items
-    waiting                   List active waiting-for items
-    someday                   List active someday/maybe items
-    next                      List active next actions
+    process <id>              Clarify a capture item into a next action, project,
+                              waiting-for or someday item (or trash it); the full
+                              capture text is kept as a note
+    waiting                   List active waiting-for items (--overdue for the ones
+                              past their follow-up or expected date, which the
+                              dashboard also lists under NEEDS A NUDGE)
+    someday                   List active someday/maybe items (--category <name>)
+        review                Go through items not reviewed in --weeks <n> weeks
+                              (default 4) to keep, delete, activate as next action
+                              or promote to project
+    next [@context]           List next actions and project next actions by context
+        --context <name>      Only list actions in the context or its children
+        --no-context          Only list actions without a context
+        --time <duration>     Only list actions estimated to fit, e.g. 15m
+        --energy <level>      Only list actions needing at most low/medium/high energy
+    now                       List next actions whose context is available right now
+                              (accepts the --time and --energy filters of next)
+    unschedule <context>      Make a context always available again
     all                       Show all active and calendar tasks
     all <project>             Show all tasks under a specific project
-
-    read                      Review tasks marked for reading (or someday if none)
-    review                    Review weekly progress and missed calendar tasks
-    edit <type> <id>          Edit an existing item (still in development)
+    projects                  List active projects with their progress, overdue
+                              tasks, next due date, last activity and age
+                              (--done for finished ones, --archived for archived
+                              ones, --all for every one)
+    contexts                  List contexts as a tree
+    templates                 List project templates, files <name>.txt under
+                              ~/.tudo/templates with "- task @context +3d" lines
+    areas                     List areas of responsibility and higher horizons
+                              with the active projects of each area
+
+    Listings of tasks accept --sort priority|due|created|project (default priority,
+    then due date); in, waiting, someday and projects accept --sort created.
+    Every listing accepts --tag <tags> and --not-tag <tags> (comma separated).
+
+    read                      List the unread reading list, oldest first (--time
+                              <duration> for items that fit, --count for the number
+                              of unread items and their total reading time)
+    review                    Review weekly progress, stalled projects, areas
+                              without active projects and missed calendar tasks
+                              (--days <n> as for stalled)
+    stalled                   List active projects without remaining or actionable
+                              tasks, or untouched for --days <n> days (default 14)
+    note <type> <id>          Add a note to an item with $EDITOR (or --text <text>)
+    show <type> <id>          Show every field of an item with its notes and history
+                              (task, project, waiting, someday, read or in; --json
+                              for JSON)
+    edit <type> <id>          Edit an existing item, prompting for each field
+        note <id>             Edit a note with $EDITOR (emptying it deletes it)
+        task <id>             Edit a task, or set fields with --content, --due,
+                              --context, --estimate, --energy, --priority and
+                              --person (- clears)
+        project <id>          Edit a project, or set fields with --order, --outcome,
+                              --purpose, --area and --parent; --ref <link|path> adds
+                              reference material (repeat it for several) and
+                              --unref <ref ids> removes it
+        someday <id>          Edit a someday item, or set --content and --category
+        waiting <id>          Edit a waiting-for item, or set fields with --content,
+                              --delegate, --delegated, --expected and --follow-up
+        <type> <id>           Any item: --tag <tags> adds tags, --untag <tags> removes them
     clean                     Remove completed items from all lists
     undo                      Undo the last completed action
 `)
@@ -184,94 +309,111 @@ Commands:
 					captureTxt = captureTxt + line
 				}
 			}
-			capture.New(db, captureTxt)
+			id, err := capture.New(db, captureTxt)
+			if err != nil {
+				fatalError(err)
+			}
+			applyTags(db, flags, "capture", id)
 			fmt.Println("Created new capture")
 
 		case "next":
 			fmt.Print("Please enter new next action: ")
-			task, _ := reader.ReadString('\n')
-			task = strings.TrimSpace(task)
+			content, _ := reader.ReadString('\n')
+			task := tasks.TudoTask{Content: strings.TrimSpace(content)}
 
-			fmt.Print("Due date (YYYY-MM-DD) (Press ENTER if no due date): ")
-			dueStr, _ := reader.ReadString('\n')
-			dueStr = strings.TrimSpace(dueStr)
+			readTaskDetails(db, reader, flags, contextList, &task)
 
-			if dueStr != "" {
-				due, err := time.Parse("2006-01-02", dueStr)
-				if err != nil {
-					nonFatalError(err)
-				}
-				yyyy, mm, dd := time.Now().Date()
-				if due.Before(time.Date(yyyy, mm, dd, 0, 0, 0, 0, time.Now().Location())) {
-					nonFatalError(errors.New("due date has passed already"))
-				}
+			id, err := tasks.New(db, task)
+			if err != nil {
+				fatalError(err)
+			}
+			applyTags(db, flags, "tasks", id)
+
+			fmt.Println("Created new next action")
 
+		case "project":
+			projectName := strings.Join(args[2:], " ")
+			if projectName == "" {
+				fmt.Print("Please enter new project name: ")
+				projectName, _ = reader.ReadString('\n')
+				projectName = strings.TrimSpace(projectName)
+			}
+			if projectName == "" {
+				nonFatalError(errors.New("Project name cannot be empty"))
 			}
 
-			fmt.Println("Select context (Press ENTER if no context): ")
-			for k, v := range contextMap {
-				fmt.Print(fmt.Sprint(k, ". ", *v, "\n"))
+			exists, _, err := projects.ContentExists(db, projectName)
+			if err != nil {
+				fatalError(err)
+			}
+			if exists {
+				fmt.Println("Project `" + projectName + "` already exists")
+				return
 			}
-			number, _ := reader.ReadString('\n')
-			number = strings.TrimSpace(number)
 
-			if dueStr == "" {
-				if number == "" {
-					if err := tasks.New(db, task, nil, nil, nil); err != nil {
-						fatalError(err)
-					}
-				} else {
-					contextID, err := strconv.Atoi(number)
-					if err != nil {
-						nonFatalError(invalidCommandFormat)
-					}
-					if err := tasks.New(db, task, nil, contextMap[uint32(contextID)], nil); err != nil {
-						fatalError(err)
-					}
+			areaList, err := areas.GetAll(db)
+			if err != nil {
+				fatalError(err)
+			}
+			var template templates.TudoTemplate
+			start := time.Now()
+			detailFlags := flags
+			if name, ok := flags["template"]; ok {
+				template, err = templates.Load(templateDir(dbFile), name)
+				if err != nil {
+					nonFatalError(err)
 				}
-			} else {
-				if number == "" {
-					if err := tasks.New(db, task, nil, nil, &dueStr); err != nil {
-						fatalError(err)
-					}
-				} else {
-					contextID, err := strconv.Atoi(number)
+				if startStr, ok := flags["start"]; ok {
+					start, err = time.Parse("2006-01-02", startStr)
 					if err != nil {
-						nonFatalError(invalidCommandFormat)
-					}
-					if err := tasks.New(db, task, nil, contextMap[uint32(contextID)], &dueStr); err != nil {
-						fatalError(err)
+						nonFatalError(err)
 					}
 				}
+				detailFlags = templateFlags(template, flags)
 			}
 
-			fmt.Println("Created new next action")
+			project := projects.TudoProject{Content: projectName}
+			readProjectDetails(reader, detailFlags, areaList, &project)
+			if parent, ok := flags["parent"]; ok {
+				parentID, _ := lookupProject(db, strings.Fields(parent))
+				project.ParentID = &parentID
+			}
 
-		case "project":
-			fmt.Print("Please enter new project name: ")
-			projectName, _ := reader.ReadString('\n')
-			projectName = strings.TrimSpace(projectName)
+			// Resolve every context before anything is created.
+			var templateTasks []tasks.TudoTask
+			for _, tt := range template.Tasks {
+				task := tasks.TudoTask{Content: tt.Content}
+				if tt.Context != nil {
+					task.Context = contextByName(contextList, *tt.Context)
+				}
+				if tt.DueOffset != nil {
+					due := start.AddDate(0, 0, *tt.DueOffset).Format("2006-01-02")
+					task.Due = &due
+				}
+				templateTasks = append(templateTasks, task)
+			}
 
-			exists, _, err := projects.ContentExists(db, projectName)
+			id, err := projects.New(db, project)
 			if err != nil {
 				fatalError(err)
 			}
-			if exists {
-				fmt.Println("Project `" + projectName + "` already exists")
-				return
-			}
+			applyTags(db, flags, "projects", id)
 
-			if err := projects.New(db, projectName); err != nil {
-				fatalError(err)
+			for _, task := range templateTasks {
+				task.ProjectID = &id
+				if _, err := tasks.New(db, task); err != nil {
+					fatalError(err)
+				}
 			}
 
 			fmt.Println("Project `" + projectName + "` has been created")
+			if len(templateTasks) > 0 {
+				fmt.Print(fmt.Sprint("Added ", len(templateTasks), " tasks from template `", template.Name, "`\n"))
+			}
 
 		case "context":
 			fmt.Println("Currently available contexts: ")
-			for k, v := range contextMap {
-				fmt.Print(fmt.Sprint(k, ". ", *v, "\n"))
-			}
+			printContextTree(contextList)
 			fmt.Println("New context: ")
 			context, _ := reader.ReadString('\n')
 			context = strings.TrimSpace(context)
@@ -285,11 +427,154 @@ Commands:
 				return
 			}
 
-			if err := contexts.New(db, context); err != nil {
+			fmt.Println("Select parent context (Press ENTER if no parent): ")
+			number, _ := reader.ReadString('\n')
+			number = strings.TrimSpace(number)
+
+			var parentID *uint32
+			if number != "" {
+				id, err := strconv.Atoi(number)
+				if err != nil {
+					nonFatalError(invalidCommandFormat)
+				}
+				if _, ok := contextMap[uint32(id)]; !ok {
+					nonFatalError(errors.New("No context `" + number + "` exists"))
+				}
+				pID := uint32(id)
+				parentID = &pID
+			}
+
+			if err := contexts.New(db, context, parentID); err != nil {
 				fatalError(err)
 			}
 			fmt.Println("Created new context `" + context + "`")
 
+		case "person":
+			fmt.Print("Please enter the person's name: ")
+			name, _ := reader.ReadString('\n')
+			name = strings.TrimSpace(name)
+			if name == "" {
+				nonFatalError(errors.New("Person name cannot be empty"))
+			}
+
+			exists, _, err := people.NameExists(db, name)
+			if err != nil {
+				fatalError(err)
+			}
+			if exists {
+				fmt.Println("Person `" + name + "` already exists")
+				return
+			}
+			if _, err := people.New(db, name); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Added person `" + name + "`")
+
+		case "area":
+			fmt.Print("Please enter new area name: ")
+			content, _ := reader.ReadString('\n')
+			area := areas.TudoArea{Content: strings.TrimSpace(content), Horizon: areas.HorizonArea}
+			if area.Content == "" {
+				nonFatalError(errors.New("Area name cannot be empty"))
+			}
+
+			exists, _, err := areas.ContentExists(db, area.Content)
+			if err != nil {
+				fatalError(err)
+			}
+			if exists {
+				fmt.Println("Area `" + area.Content + "` already exists")
+				return
+			}
+
+			if horizon, ok := flags["horizon"]; ok {
+				area.Horizon, err = areas.ParseHorizon(horizon)
+				if err != nil {
+					nonFatalError(err)
+				}
+			}
+			if parent, ok := flags["parent"]; ok {
+				exists, parentID, err := areas.ContentExists(db, parent)
+				if err != nil {
+					fatalError(err)
+				}
+				if !exists {
+					nonFatalError(errors.New("No area `" + parent + "` exists"))
+				}
+				area.ParentID = &parentID
+			}
+
+			if _, err := areas.New(db, area); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Created new " + areas.HorizonName(area.Horizon) + " `" + area.Content + "`")
+
+		case "check":
+			if len(args) != 3 {
+				nonFatalError(invalidCommandFormat)
+			}
+			taskID, err := strconv.Atoi(args[2])
+			if err != nil {
+				nonFatalError(invalidCommandFormat)
+			}
+			exists, err := tasks.IDExists(db, uint32(taskID))
+			if err != nil {
+				fatalError(err)
+			}
+			if !exists {
+				nonFatalError(errors.New("Task `" + args[2] + "` does not exist"))
+			}
+
+			fmt.Println("Enter checklist items for task `" + args[2] + "` (empty line to finish): ")
+			added := 0
+			for {
+				item, err := reader.ReadString('\n')
+				item = strings.TrimSpace(item)
+				if item != "" {
+					if err := checklist.New(db, uint32(taskID), item); err != nil {
+						fatalError(err)
+					}
+					added++
+				}
+				if item == "" || err != nil {
+					break
+				}
+			}
+			fmt.Print(fmt.Sprint("Added ", added, " checklist items to task `", args[2], "`\n"))
+
+		case "schedule":
+			fmt.Println("Select context: ")
+			printContextTree(contextList)
+			number, _ := reader.ReadString('\n')
+			number = strings.TrimSpace(number)
+			contextID, err := strconv.Atoi(number)
+			if err != nil {
+				nonFatalError(invalidCommandFormat)
+			}
+			context, ok := contextMap[uint32(contextID)]
+			if !ok {
+				nonFatalError(errors.New("No context `" + number + "` exists"))
+			}
+
+			fmt.Print("Weekdays (e.g. mon-fri or sat,sun) (Press ENTER for every day): ")
+			daysStr, _ := reader.ReadString('\n')
+			days, err := contexts.ParseWeekdays(daysStr)
+			if err != nil {
+				nonFatalError(err)
+			}
+
+			fmt.Print("Hours (HH:MM-HH:MM) (Press ENTER for the whole day): ")
+			hoursStr, _ := reader.ReadString('\n')
+			start, end, err := contexts.ParseHours(hoursStr)
+			if err != nil {
+				nonFatalError(err)
+			}
+
+			if err := contexts.NewSchedule(db, uint32(contextID), days, start, end); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Context `" + *context + "` is available on " + days + " " + start + "-" + end)
+
 		case "wait":
 			fmt.Print("Please enter new task to wait for: ")
 			waitAction, _ := reader.ReadString('\n')
@@ -302,11 +587,17 @@ Commands:
 				fmt.Println("Waiting action `" + waitAction + "` already exists")
 				return
 			}
-			waiting.New(db, waitAction)
+			w := waiting.TudoWaiting{Content: waitAction}
+			readWaitingDetails(db, reader, flags, &w)
+			id, err := waiting.New(db, w)
+			if err != nil {
+				fatalError(err)
+			}
+			applyTags(db, flags, "waiting", id)
 			fmt.Println("Created new wait action")
 
 		case "someday":
-			fmt.Print("Please enter new project name: ")
+			fmt.Print("Please enter new someday action: ")
 
 			reader := bufio.NewReader(os.Stdin)
 
@@ -322,12 +613,33 @@ Commands:
 				return
 			}
 
-			if err := someday.New(db, futureTask); err != nil {
+			item := someday.TudoSomeday{Content: futureTask}
+			if category := promptOrFlag(reader, flags, "category", "Category (e.g. books, trips) (Press ENTER to skip): "); category != "" {
+				item.Category = &category
+			}
+			id, err := someday.New(db, item)
+			if err != nil {
 				fatalError(err)
 			}
+			applyTags(db, flags, "someday", id)
 
 			fmt.Println("Someday action `" + futureTask + "` has been created")
 
+		case "read":
+			item := reading.TudoReading{Title: promptOrFlag(reader, flags, "title", "Please enter title: ")}
+			if item.Title == "" {
+				nonFatalError(invalidCommandFormat)
+			}
+			readReadingDetails(reader, flags, &item)
+
+			id, err := reading.New(db, item)
+			if err != nil {
+				fatalError(err)
+			}
+			applyTags(db, flags, "reading", id)
+
+			fmt.Println("Added `" + item.Title + "` to the reading list")
+
 		default:
 			projectName := ""
 			for i := 1; i < len(args); i++ {
@@ -347,68 +659,24 @@ Commands:
 			}
 
 			fmt.Print("Please enter new task for the project `" + projectName + "`: ")
-			task, _ := reader.ReadString('\n')
-			task = strings.TrimSpace(task)
-
-			fmt.Print("Due date (YYYY-MM-DD) (Press ENTER if no due date): ")
-			dueStr, _ := reader.ReadString('\n')
-			dueStr = strings.TrimSpace(dueStr)
-
-			if dueStr != "" {
-				due, err := time.Parse("2006-01-02", dueStr)
-				if err != nil {
-					nonFatalError(err)
-				}
-				yyyy, mm, dd := time.Now().Date()
-				if due.Before(time.Date(yyyy, mm, dd, 0, 0, 0, 0, time.Now().Location())) {
-					nonFatalError(errors.New("due date has passed already"))
-				}
-			}
-
-			fmt.Println("Please enter context (Press ENTER for no context): ")
-			for k, v := range contextMap {
-				fmt.Print(fmt.Sprint(k, ". ", *v, "\n"))
-			}
-			number, _ := reader.ReadString('\n')
-			number = strings.TrimSpace(number)
-
-			if dueStr == "" {
-				if number == "" {
-					if err := tasks.New(db, task, &projectID, nil, nil); err != nil {
-						fatalError(err)
-					}
-				} else {
-					contextID, err := strconv.Atoi(number)
-					if err != nil {
-						nonFatalError(invalidCommandFormat)
-					}
+			content, _ := reader.ReadString('\n')
+			task := tasks.TudoTask{Content: strings.TrimSpace(content), ProjectID: &projectID}
 
-					if err := tasks.New(db, task, &projectID, contextMap[uint32(contextID)], nil); err != nil {
-						fatalError(err)
-					}
-				}
-			} else {
-				if number == "" {
-					if err := tasks.New(db, task, &projectID, nil, &dueStr); err != nil {
-						fatalError(err)
-					}
-				} else {
-					contextID, err := strconv.Atoi(number)
-					if err != nil {
-						nonFatalError(invalidCommandFormat)
-					}
+			readTaskDetails(db, reader, flags, contextList, &task)
 
-					if err := tasks.New(db, task, &projectID, contextMap[uint32(contextID)], &dueStr); err != nil {
-						fatalError(err)
-					}
-				}
+			id, err := tasks.New(db, task)
+			if err != nil {
+				fatalError(err)
 			}
+			applyTags(db, flags, "tasks", id)
 
 			fmt.Println("New task created for `" + projectName + "`")
 		}
 
 	case "done":
-		if len(args) < 3 {
+		// Items take a type and an id, a project only its name, which may be
+		// a single word.
+		if len(args) < 2 || (len(args) == 2 && slices.Contains([]string{"in", "someday", "waiting", "task", "read"}, args[1])) {
 			nonFatalError(invalidCommandFormat)
 		}
 		switch args[1] {
@@ -488,7 +756,7 @@ Commands:
 						return
 					}
 
-					if err := projects.New(db, projectName); err != nil {
+					if _, err := projects.New(db, projects.TudoProject{Content: projectName}); err != nil {
 						fatalError(err)
 					}
 
@@ -508,7 +776,7 @@ Commands:
 						return
 					}
 
-					if err := projects.New(db, task.Content); err != nil {
+					if _, err := projects.New(db, projects.TudoProject{Content: task.Content}); err != nil {
 						fatalError(err)
 					}
 
@@ -520,6 +788,34 @@ Commands:
 				nonFatalError(invalidCommand, ans)
 			}
 
+		case "read":
+			id, err := strconv.Atoi(args[2])
+			if err != nil {
+				nonFatalError(invalidCommandFormat)
+			}
+
+			item, err := reading.Get(db, uint32(id))
+			if errors.Is(err, sql.ErrNoRows) {
+				fmt.Println("Reading list item `" + args[2] + "` does not exist")
+				return
+			} else if err != nil {
+				fatalError(err)
+			}
+			if item.Done {
+				fmt.Println("Reading list item `" + args[2] + "` is already read")
+				return
+			}
+
+			if err := reading.Done(db, uint32(id)); err != nil {
+				fatalError(err)
+			}
+
+			fmt.Println("Marked reading list item `" + args[2] + "` as read")
+
+			if err := log.New(db, "reading", uint32(id)); err != nil {
+				fatalError(err)
+			}
+
 		case "waiting":
 			if len(args) < 2 {
 				nonFatalError(invalidCommandFormat)
@@ -534,6 +830,7 @@ Commands:
 			}
 			if !exists {
 				fmt.Println("Waiting action `" + args[2] + "` does not exist")
+				return
 			}
 
 			if err := waiting.Done(db, uint32(id)); err != nil {
@@ -546,157 +843,1032 @@ Commands:
 				fatalError(err)
 			}
 
+			// Delegated items usually need something done once they are
+			// back, offer a task in the same project and context.
+			w, err := waiting.Get(db, uint32(id))
+			if err != nil {
+				fatalError(err)
+			}
+			content, ok := flags["next"]
+			if !ok {
+				if w.ProjectID == nil && w.PersonID == nil {
+					return
+				}
+				fmt.Println("Create a follow-up task? (y/n)")
+				var ans string
+				fmt.Scanln(&ans)
+				switch ans {
+				case "n":
+					return
+				case "y":
+					content = promptOrFlag(bufio.NewReader(os.Stdin), flags, "next", "Follow-up task (Press ENTER for `Process: "+w.Content+"`): ")
+				default:
+					nonFatalError(invalidCommand, ans)
+				}
+			}
+			if content == "" {
+				content = "Process: " + w.Content
+			}
+
+			taskID, err := tasks.New(db, tasks.TudoTask{Content: content, ProjectID: w.ProjectID, Context: w.Context})
+			if err != nil {
+				fatalError(err)
+			}
+			if err := log.NewAction(db, "tasks", taskID, fmt.Sprint("created from waiting ", id)); err != nil {
+				fatalError(err)
+			}
+			fmt.Print(fmt.Sprint("Created follow-up task `", taskID, "`\n`", content, "`\n"))
+
 		case "task":
 			taskID, err := strconv.Atoi(args[2])
 			if err != nil {
 				nonFatalError(invalidCommandFormat)
 			}
 
-			exists, err := tasks.IDExists(db, uint32(taskID))
+			exists, err := tasks.IDExists(db, uint32(taskID))
+			if err != nil {
+				fatalError(err)
+			}
+			if !exists {
+				fmt.Print(fmt.Sprint("Task ", args[2], " does not exist\n"))
+			}
+
+			finishTask(db, uint32(taskID))
+
+		default:
+			projectName := ""
+			for i := 1; i < len(args); i++ {
+				if i > 1 {
+					projectName += " "
+				}
+				projectName += args[i]
+			}
+			exists, projectID, err := projects.ContentExists(db, projectName)
+			if err != nil {
+				fatalError(err)
+			}
+			if !exists {
+				fmt.Println("No active project `" + projectName + "` exists")
+				return
+			}
+
+			_, cascade := flags["cascade"]
+			tree, err := projects.Tree(db, projectID)
+			if err != nil {
+				fatalError(err)
+			}
+			var openTasks []tasks.TudoTask
+			for i, n := range tree {
+				if n.Project.Done {
+					continue
+				}
+				if i > 0 && !cascade {
+					nonFatalError(errors.New("Project `" + projectName + "` still has open sub-projects, finish them first or pass --cascade"))
+				}
+				projectTasks, err := tasks.GetOpenProjectTasks(db, n.Project.ID)
+				if err != nil {
+					fatalError(err)
+				}
+				openTasks = append(openTasks, projectTasks...)
+			}
+
+			var resolution projects.TudoResolution
+			if len(openTasks) > 0 {
+				fmt.Print(fmt.Sprint("Project `", projectName, "` has ", len(openTasks), " open tasks:\n"))
+				for _, t := range openTasks {
+					printTask(db, t, cascade)
+				}
+				resolution = readResolution(db, bufio.NewReader(os.Stdin), flags)
+			}
+
+			finished, err := projects.Done(db, projectID, cascade, resolution)
+			if errors.Is(err, projects.ErrMoveTarget) {
+				nonFatalError(err)
+			} else if err != nil {
+				fatalError(err)
+			}
+
+			if len(openTasks) > 0 {
+				fmt.Print(fmt.Sprint("Resolved ", len(openTasks), " open tasks: ", resolution.Action, "\n"))
+			}
+
+			for _, id := range finished {
+				if id == projectID {
+					continue
+				}
+				p, err := projects.Get(db, id)
+				if err != nil {
+					fatalError(err)
+				}
+				fmt.Println("Finished sub-project `" + p.Content + "`")
+			}
+			fmt.Println("Finished project `" + projectName + "`\n")
+		}
+
+	case "in":
+		captureList, err := capture.GetActive(db)
+		if err != nil {
+			fatalError(err)
+		}
+
+		match := tagMatcher(db, flags, "capture")
+		captureList = slices.DeleteFunc(captureList, func(c capture.TudoCapture) bool { return !match(c.ID) })
+		if len(captureList) == 0 {
+			fmt.Println("No next actions for now")
+		}
+		if sortKey(flags, "", "created") == "created" {
+			sort.SliceStable(captureList, func(i, j int) bool { return captureList[i].CreatedAt < captureList[j].CreatedAt })
+		}
+
+		for _, c := range captureList {
+			fmt.Print(fmt.Sprint("- ID: ", c.ID, "\n", c.Content, "\n"))
+			printTags(db, "capture", c.ID)
+		}
+
+	case "waiting":
+		waitList, err := waiting.GetActive(db)
+		if _, ok := flags["overdue"]; ok {
+			waitList, err = waiting.GetOverdue(db)
+		}
+		if err != nil {
+			fatalError(err)
+		}
+		match := tagMatcher(db, flags, "waiting")
+		waitList = slices.DeleteFunc(waitList, func(w waiting.TudoWaiting) bool { return !match(w.ID) })
+		if len(waitList) == 0 {
+			fmt.Println("No tasks to wait for now")
+		}
+		if sortKey(flags, "", "created") == "created" {
+			sort.SliceStable(waitList, func(i, j int) bool { return waitList[i].CreatedAt < waitList[j].CreatedAt })
+		}
+		for _, w := range waitList {
+			printWaiting(w)
+			printTags(db, "waiting", w.ID)
+		}
+
+	case "someday":
+		if len(args) > 1 && args[1] == "review" {
+			reviewSomeday(db, flags)
+			return
+		}
+
+		futureTasks, err := someday.GetActive(db)
+		if err != nil {
+			fatalError(err)
+		}
+		if category, ok := flags["category"]; ok {
+			var inCategory []someday.TudoSomeday
+			for _, s := range futureTasks {
+				if s.Category != nil && *s.Category == category {
+					inCategory = append(inCategory, s)
+				}
+			}
+			futureTasks = inCategory
+		}
+		match := tagMatcher(db, flags, "someday")
+		futureTasks = slices.DeleteFunc(futureTasks, func(s someday.TudoSomeday) bool { return !match(s.ID) })
+		if len(futureTasks) == 0 {
+			fmt.Println("No tasks for someday")
+		}
+		if sortKey(flags, "", "created") == "created" {
+			sort.SliceStable(futureTasks, func(i, j int) bool { return futureTasks[i].CreatedAt < futureTasks[j].CreatedAt })
+		}
+		for _, s := range futureTasks {
+			printSomeday(s)
+			printTags(db, "someday", s.ID)
+		}
+
+	case "read":
+		readingList, err := reading.GetUnread(db)
+		if err != nil {
+			fatalError(err)
+		}
+		match := tagMatcher(db, flags, "reading")
+		readingList = slices.DeleteFunc(readingList, func(r reading.TudoReading) bool { return !match(r.ID) })
+
+		if _, ok := flags["count"]; ok {
+			minutes := 0
+			for _, r := range readingList {
+				if r.Minutes != nil {
+					minutes += int(*r.Minutes)
+				}
+			}
+			fmt.Print(fmt.Sprint(len(readingList), " unread"))
+			if minutes > 0 {
+				fmt.Print(", about " + tasks.FormatEstimate(uint32(minutes)))
+			}
+			fmt.Println()
+			return
+		}
+
+		if timeStr, ok := flags["time"]; ok {
+			available, err := tasks.ParseEstimate(timeStr)
+			if err != nil {
+				nonFatalError(err)
+			}
+			readingList = slices.DeleteFunc(readingList, func(r reading.TudoReading) bool {
+				return r.Minutes == nil || *r.Minutes > available
+			})
+		}
+		if len(readingList) == 0 {
+			fmt.Println("Nothing to read for now")
+		}
+		for _, r := range readingList {
+			printReading(r)
+			printTags(db, "reading", r.ID)
+		}
+
+	case "next":
+		nextActions := filterTasksByTag(db, flags, actionableTasks(db))
+
+		contextName, filterContext := flags["context"]
+		if len(args) > 1 {
+			if !strings.HasPrefix(args[1], "@") || filterContext {
+				nonFatalError(invalidCommandFormat)
+			}
+			contextName, filterContext = args[1], true
+		}
+		_, noContext := flags["no-context"]
+		if filterContext && noContext {
+			nonFatalError(invalidCommandFormat)
+		}
+
+		if filterContext {
+			names, err := contextSubtree(db, contextName)
+			if err != nil {
+				nonFatalError(err)
+			}
+			filtered := nextActions[:0]
+			for _, t := range nextActions {
+				if t.Context != nil && names[*t.Context] {
+					filtered = append(filtered, t)
+				}
+			}
+			nextActions = filtered
+		} else if noContext {
+			filtered := nextActions[:0]
+			for _, t := range nextActions {
+				if t.Context == nil {
+					filtered = append(filtered, t)
+				}
+			}
+			nextActions = filtered
+		}
+
+		nextActions = filterEffort(nextActions, flags)
+		sortTasks(db, nextActions, sortKey(flags, "priority", taskSortKeys...))
+
+		if len(nextActions) == 0 {
+			fmt.Println("No next actions for now")
+		}
+
+		printByContext(db, nextActions)
+
+	case "edit":
+		if len(args) != 3 {
+			nonFatalError(invalidCommandFormat)
+		}
+		id, err := strconv.Atoi(args[2])
+		if err != nil {
+			nonFatalError(invalidCommandFormat)
+		}
+
+		if args[1] == "note" {
+			note, err := notes.Get(db, uint32(id))
+			if errors.Is(err, sql.ErrNoRows) {
+				nonFatalError(errors.New("No note `" + args[2] + "` exists"))
+			} else if err != nil {
+				fatalError(err)
+			}
+
+			content := editText(note.Content)
+			if content == "" {
+				if err := notes.Delete(db, note.ID); err != nil {
+					fatalError(err)
+				}
+				fmt.Println("Deleted note `" + args[2] + "`")
+				return
+			}
+			if err := notes.Update(db, note.ID, content); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Updated note `" + args[2] + "`")
+			return
+		}
+
+		table := itemTable(args[1])
+		if !itemExists(db, args[1], uint32(id)) {
+			nonFatalError(errors.New("No " + args[1] + " `" + args[2] + "` exists"))
+		}
+
+		fieldFlags := make(map[string]string)
+		for k, v := range flags {
+			if k != "tag" && k != "untag" && k != "ref" && k != "unref" {
+				fieldFlags[k] = v
+			}
+		}
+
+		switch args[1] {
+		case "task":
+			if len(flags) == 0 || len(fieldFlags) > 0 {
+				task, err := tasks.Get(db, uint32(id))
+				if err != nil {
+					fatalError(err)
+				}
+				contextList, err := contexts.GetAll(db)
+				if err != nil {
+					fatalError(err)
+				}
+
+				editTaskDetails(db, bufio.NewReader(os.Stdin), fieldFlags, contextList, &task)
+
+				if err := tasks.Update(db, task); err != nil {
+					fatalError(err)
+				}
+			}
+
+		case "someday":
+			if len(flags) == 0 || len(fieldFlags) > 0 {
+				item, err := someday.Get(db, uint32(id))
+				if err != nil {
+					fatalError(err)
+				}
+
+				reader := bufio.NewReader(os.Stdin)
+				if v, ok := editValue(reader, fieldFlags, "content", "Content", &item.Content); ok && v != "-" {
+					item.Content = v
+				}
+				if v, ok := editValue(reader, fieldFlags, "category", "Category", item.Category); ok {
+					item.Category = &v
+					if v == "-" || v == "" {
+						item.Category = nil
+					}
+				}
+
+				if err := someday.Update(db, item); err != nil {
+					fatalError(err)
+				}
+			}
+
+		case "waiting":
+			if len(flags) == 0 || len(fieldFlags) > 0 {
+				w, err := waiting.Get(db, uint32(id))
+				if err != nil {
+					fatalError(err)
+				}
+
+				editWaitingDetails(db, bufio.NewReader(os.Stdin), fieldFlags, &w)
+
+				if err := waiting.Update(db, w); err != nil {
+					fatalError(err)
+				}
+			}
+
+		case "project":
+			project, err := projects.Get(db, uint32(id))
+			if err != nil {
+				fatalError(err)
+			}
+			areaList, err := areas.GetAll(db)
+			if err != nil {
+				fatalError(err)
+			}
+			reader := bufio.NewReader(os.Stdin)
+			if len(flags) == 0 || len(fieldFlags) > 0 {
+				editProjectDetails(reader, fieldFlags, areaList, &project)
+				if parent, ok := fieldFlags["parent"]; ok {
+					project.ParentID = nil
+					if parent != "-" && parent != "" {
+						parentID, _ := lookupProject(db, strings.Fields(parent))
+						project.ParentID = &parentID
+					}
+				}
+				if err := projects.Update(db, project); errors.Is(err, projects.ErrProjectCycle) {
+					nonFatalError(err)
+				} else if err != nil {
+					fatalError(err)
+				}
+			}
+
+			if refs, ok := flagValues["ref"]; ok {
+				for _, ref := range refs {
+					if err := projects.AddReference(db, project.ID, ref); err != nil {
+						fatalError(err)
+					}
+				}
+			} else if len(flags) == 0 {
+				fmt.Print("Add reference link or file path (Press ENTER to skip): ")
+				ref, _ := reader.ReadString('\n')
+				if ref = strings.TrimSpace(ref); ref != "" {
+					if err := projects.AddReference(db, project.ID, ref); err != nil {
+						fatalError(err)
+					}
+				}
+			}
+			for _, refID := range strings.Split(flags["unref"], ",") {
+				if refID == "" {
+					continue
+				}
+				rID, err := strconv.Atoi(refID)
+				if err != nil {
+					nonFatalError(invalidCommandFormat)
+				}
+				if err := projects.RemoveReference(db, project.ID, uint32(rID)); err != nil {
+					fatalError(err)
+				}
+			}
+
+		default:
+			if len(flags) == 0 || len(fieldFlags) > 0 {
+				todo()
+			}
+		}
+
+		applyTags(db, flags, table, uint32(id))
+		if err := log.NewAction(db, table, uint32(id), "edited"); err != nil {
+			fatalError(err)
+		}
+		fmt.Println("Updated " + args[1] + " `" + args[2] + "`")
+
+	case "project":
+		if len(args) < 3 {
+			nonFatalError(invalidCommandFormat)
+		}
+
+		switch args[1] {
+		case "sequential", "parallel":
+			projectID, projectName := lookupProject(db, args[2:])
+			if err := projects.SetSequential(db, projectID, args[1] == "sequential"); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Project `" + projectName + "` is now " + args[1])
+
+		case "rename":
+			projectID, projectName := lookupAnyProject(db, args[2:])
+			newName := promptOrFlag(bufio.NewReader(os.Stdin), flags, "to", "New project name: ")
+			if newName == "" {
+				nonFatalError(invalidCommandFormat)
+			}
+			exists, _, err := projects.ContentExists(db, newName)
+			if err != nil {
+				fatalError(err)
+			}
+			if exists {
+				nonFatalError(errors.New("Project `" + newName + "` already exists"))
+			}
+
+			if err := projects.Rename(db, projectID, newName); err != nil {
+				fatalError(err)
+			}
+			if err := log.NewAction(db, "projects", projectID, "renamed from "+projectName); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Renamed project `" + projectName + "` to `" + newName + "`")
+
+		case "reopen":
+			projectID, projectName := lookupAnyProject(db, args[2:])
+			p, err := projects.Get(db, projectID)
+			if err != nil {
+				fatalError(err)
+			}
+			if !p.Done {
+				fmt.Println("Project `" + projectName + "` is already active")
+				return
+			}
+			exists, _, err := projects.ContentExists(db, projectName)
+			if err != nil {
+				fatalError(err)
+			}
+			if exists {
+				nonFatalError(errors.New("Another active project is named `" + projectName + "`, rename one of them first"))
+			}
+
+			if err := projects.Reopen(db, projectID); err != nil {
+				fatalError(err)
+			}
+			if err := log.NewAction(db, "projects", projectID, "reopened"); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Reopened project `" + projectName + "`")
+
+		case "archive":
+			projectID, projectName := lookupAnyProject(db, args[2:])
+			if err := projects.Archive(db, projectID); errors.Is(err, projects.ErrArchived) {
+				nonFatalError(err)
+			} else if err != nil {
+				fatalError(err)
+			}
+			if err := log.NewAction(db, "projects", projectID, "archived"); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Archived project `" + projectName + "`")
+
+		case "reorder":
+			if len(args) != 4 {
+				nonFatalError(invalidCommandFormat)
+			}
+			taskID, err := strconv.Atoi(args[2])
+			if err != nil {
+				nonFatalError(invalidCommandFormat)
+			}
+			position, err := strconv.Atoi(args[3])
+			if err != nil {
+				nonFatalError(invalidCommandFormat)
+			}
+			exists, err := tasks.IDExists(db, uint32(taskID))
+			if err != nil {
+				fatalError(err)
+			}
+			if !exists {
+				nonFatalError(errors.New("Task `" + args[2] + "` does not exist"))
+			}
+
+			if err := tasks.Reorder(db, uint32(taskID), position); err != nil {
+				nonFatalError(err)
+			}
+			fmt.Println("Moved task `" + args[2] + "` to position " + args[3])
+
+		default:
+			nonFatalError(invalidCommand, args[1])
+		}
+
+	case "delegate":
+		if len(args) != 3 || args[1] != "task" {
+			nonFatalError(invalidCommandFormat)
+		}
+		id, err := strconv.Atoi(args[2])
+		if err != nil {
+			nonFatalError(invalidCommandFormat)
+		}
+		// Everything is checked before the person is added and the task is
+		// converted.
+		task, err := tasks.Get(db, uint32(id))
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && task.Done) {
+			nonFatalError(move.ErrNotActive)
+		} else if err != nil {
+			fatalError(err)
+		}
+		to := move.TudoDestination{Table: "waiting"}
+		for flag, field := range map[string]**string{"expected": &to.ExpectedBy, "follow-up": &to.FollowUp} {
+			if v, ok := flags[flag]; ok {
+				validateDate(v)
+				*field = &v
+			}
+		}
+		peopleList, err := people.GetAll(db)
+		if err != nil {
+			fatalError(err)
+		}
+		reader := bufio.NewReader(os.Stdin)
+		if _, ok := flags["to"]; !ok {
+			printPeople(peopleList)
+		}
+		to.PersonID = personByInput(db, peopleList, promptOrFlag(reader, flags, "to", "Delegate to: "))
+		if to.PersonID == nil {
+			nonFatalError(invalidCommandFormat)
+		}
+
+		waitingID, err := move.Item(db, "tasks", uint32(id), to)
+		if errors.Is(err, move.ErrNotActive) {
+			nonFatalError(err)
+		} else if err != nil {
+			fatalError(err)
+		}
+
+		w, err := waiting.Get(db, waitingID)
+		if err != nil {
+			fatalError(err)
+		}
+		if err := log.NewAction(db, "waiting", waitingID, fmt.Sprint("delegated from tasks ", id)); err != nil {
+			fatalError(err)
+		}
+		fmt.Print(fmt.Sprint("Delegated task `", id, "` to ", *w.Delegate, " as waiting `", waitingID, "`\n"))
+
+	case "move":
+		if len(args) != 3 {
+			nonFatalError(invalidCommandFormat)
+		}
+		table := itemTable(args[1])
+		if table != "tasks" && table != "someday" && table != "waiting" {
+			nonFatalError(invalidCommand, args[1])
+		}
+		id, err := strconv.Atoi(args[2])
+		if err != nil {
+			nonFatalError(invalidCommandFormat)
+		}
+
+		var to move.TudoDestination
+		var target string
+		_, toNext := flags["to-next"]
+		_, toSomeday := flags["to-someday"]
+		_, toWaiting := flags["to-waiting"]
+		switch project, toProject := flags["to-project"]; {
+		case toProject:
+			projectID, projectName := lookupProject(db, strings.Fields(project))
+			to = move.TudoDestination{Table: "tasks", ProjectID: &projectID}
+			target = "project `" + projectName + "`"
+		case toNext:
+			to = move.TudoDestination{Table: "tasks"}
+			target = "next actions"
+		case toSomeday:
+			to = move.TudoDestination{Table: "someday"}
+			target = "someday/maybe"
+		case toWaiting:
+			to = move.TudoDestination{Table: "waiting"}
+			target = "waiting for"
+		default:
+			nonFatalError(errors.New("Expected --to-project <name|id>, --to-next, --to-someday or --to-waiting"))
+		}
+
+		newID, err := move.Item(db, table, uint32(id), to)
+		if errors.Is(err, move.ErrNotActive) || errors.Is(err, move.ErrSameList) {
+			nonFatalError(err)
+		} else if err != nil {
+			fatalError(err)
+		}
+		if err := log.NewAction(db, to.Table, newID, fmt.Sprint("moved from ", table, " ", id)); err != nil {
+			fatalError(err)
+		}
+		fmt.Print(fmt.Sprint("Moved ", args[1], " `", id, "` to ", target, " as `", newID, "`\n"))
+
+	case "agenda":
+		if len(args) < 2 {
+			nonFatalError(invalidCommandFormat)
+		}
+		name := strings.Join(args[1:], " ")
+		exists, personID, err := people.NameExists(db, name)
+		if err != nil {
+			fatalError(err)
+		}
+		if !exists {
+			nonFatalError(errors.New("No person `" + name + "` exists"))
+		}
+
+		waitList, err := waiting.GetForPerson(db, personID)
+		if err != nil {
+			fatalError(err)
+		}
+		agenda, err := tasks.GetForPerson(db, personID)
+		if err != nil {
+			fatalError(err)
+		}
+		match := tagMatcher(db, flags, "waiting")
+		waitList = slices.DeleteFunc(waitList, func(w waiting.TudoWaiting) bool { return !match(w.ID) })
+		agenda = filterTasksByTag(db, flags, agenda)
+
+		fmt.Println("WAITING ON " + strings.ToUpper(name))
+		for _, w := range waitList {
+			printWaiting(w)
+		}
+		fmt.Println("\nTO DISCUSS WITH " + strings.ToUpper(name))
+		for _, t := range agenda {
+			printTask(db, t, true)
+		}
+
+	case "people":
+		peopleList, err := people.GetAll(db)
+		if err != nil {
+			fatalError(err)
+		}
+		counts, err := people.Counts(db)
+		if err != nil {
+			fatalError(err)
+		}
+		if len(peopleList) == 0 {
+			fmt.Println("No people")
+		}
+		for _, p := range peopleList {
+			c := counts[p.ID]
+			fmt.Print(fmt.Sprint("- ID: ", p.ID, "\n", p.Name, "\n", "Waiting on: ", c.Waiting, ", to discuss: ", c.Agenda, "\n"))
+		}
+
+	case "stalled":
+		stalledProjects, err := projects.Stalled(db, idleDays(flags))
+		if err != nil {
+			fatalError(err)
+		}
+		if len(stalledProjects) == 0 {
+			fmt.Println("No stalled projects")
+		}
+		printStalled(stalledProjects)
+
+	case "block", "unblock":
+		if len(args) != 2 {
+			nonFatalError(invalidCommandFormat)
+		}
+		taskID, err := strconv.Atoi(args[1])
+		if err != nil {
+			nonFatalError(invalidCommandFormat)
+		}
+		blockerID, err := strconv.Atoi(flags["by"])
+		if err != nil {
+			nonFatalError(invalidCommandFormat)
+		}
+		for _, id := range []int{taskID, blockerID} {
+			exists, err := tasks.IDExists(db, uint32(id))
 			if err != nil {
 				fatalError(err)
 			}
 			if !exists {
-				fmt.Print(fmt.Sprint("Task ", args[2], " does not exist\n"))
+				nonFatalError(errors.New(fmt.Sprint("Task `", id, "` does not exist")))
 			}
+		}
 
-			if err := tasks.Done(db, uint32(taskID)); err != nil {
+		if args[0] == "unblock" {
+			if err := tasks.RemoveDependency(db, uint32(taskID), uint32(blockerID)); err != nil {
 				fatalError(err)
 			}
+			fmt.Print(fmt.Sprint("Task `", taskID, "` no longer waits on task `", blockerID, "`\n"))
+			return
+		}
 
-			task, err := tasks.Get(db, uint32(taskID))
-			if err != nil {
-				fatalError(err)
-			}
+		err = tasks.AddDependency(db, uint32(taskID), uint32(blockerID))
+		if errors.Is(err, tasks.ErrDependencyCycle) {
+			nonFatalError(err)
+		} else if err != nil {
+			fatalError(err)
+		}
+		fmt.Print(fmt.Sprint("Task `", taskID, "` is blocked by task `", blockerID, "`\n"))
 
-			fmt.Println("Finished task `" + args[2] + "`\n`" + task.Content + "`")
+	case "blocked":
+		blocked, err := tasks.GetBlocked(db)
+		if err != nil {
+			fatalError(err)
+		}
+		blocked = filterTasksByTag(db, flags, blocked)
+		if len(blocked) == 0 {
+			fmt.Println("No blocked tasks")
+		}
+		sortTasks(db, blocked, sortKey(flags, "priority", taskSortKeys...))
+		for _, t := range blocked {
+			printTask(db, t, true)
+		}
 
-			if err := log.New(db, "tasks", uint32(taskID)); err != nil {
-				fatalError(err)
-			}
+	case "check", "uncheck":
+		if len(args) != 2 {
+			nonFatalError(invalidCommandFormat)
+		}
+		itemID, err := strconv.Atoi(args[1])
+		if err != nil {
+			nonFatalError(invalidCommandFormat)
+		}
+		item, err := checklist.Get(db, uint32(itemID))
+		if errors.Is(err, sql.ErrNoRows) {
+			nonFatalError(errors.New("Checklist item `" + args[1] + "` does not exist"))
+		} else if err != nil {
+			fatalError(err)
+		}
 
-		default:
-			projectName := ""
-			for i := 1; i < len(args); i++ {
-				if i > 1 {
-					projectName += " "
-				}
-				projectName += args[i]
-			}
-			exists, projectID, err := projects.ContentExists(db, projectName)
-			if err != nil {
+		if args[0] == "uncheck" {
+			if err := checklist.Uncheck(db, item.ID); err != nil {
 				fatalError(err)
 			}
-			if !exists {
-				fmt.Println("No active project `" + projectName + "` exists")
-				return
-			}
+			fmt.Println("Unchecked `" + item.Content + "`")
+			return
+		}
 
-			if err := projects.Done(db, projectID); err != nil {
-				fatalError(err)
-			}
+		if err := checklist.Check(db, item.ID); err != nil {
+			fatalError(err)
+		}
+		fmt.Println("Checked `" + item.Content + "`")
 
-			fmt.Println("Finished project `" + projectName + "`\n")
+		done, total, err := checklist.Progress(db, item.TaskID)
+		if err != nil {
+			fatalError(err)
+		}
+		task, err := tasks.Get(db, item.TaskID)
+		if err != nil {
+			fatalError(err)
+		}
+		if done < total || task.Done {
+			return
+		}
 
-			if err := log.New(db, "projects", projectID); err != nil {
-				fatalError(err)
-			}
+		fmt.Print(fmt.Sprint("All checklist items of task `", task.ID, "` are done. Mark `", task.Content, "` as done? (y/n)\n"))
+		var ans string
+		fmt.Scanln(&ans)
+		switch ans {
+		case "n":
+		case "y":
+			finishTask(db, task.ID)
+		default:
+			nonFatalError(invalidCommand, ans)
 		}
 
-	case "in":
-		captureList, err := capture.GetActive(db)
+	case "note":
+		if len(args) != 3 {
+			nonFatalError(invalidCommandFormat)
+		}
+		id, err := strconv.Atoi(args[2])
 		if err != nil {
-			fatalError(err)
+			nonFatalError(invalidCommandFormat)
+		}
+		table := itemTable(args[1])
+		if !itemExists(db, args[1], uint32(id)) {
+			nonFatalError(errors.New("No " + args[1] + " `" + args[2] + "` exists"))
 		}
 
-		if len(captureList) == 0 {
-			fmt.Println("No next actions for now")
+		content, ok := flags["text"]
+		if !ok {
+			content = editText("")
+		}
+		content = strings.TrimSpace(content)
+		if content == "" {
+			fmt.Println("Empty note, nothing saved")
+			return
 		}
 
-		for _, c := range captureList {
-			fmt.Print(fmt.Sprint("- ID: ", c.ID, "\n", c.Content, "\n"))
+		if err := notes.New(db, table, uint32(id), content); err != nil {
+			fatalError(err)
 		}
+		fmt.Println("Added note to " + args[1] + " `" + args[2] + "`")
 
-	case "waiting":
-		waitList, err := waiting.GetActive(db)
+	case "show":
+		if len(args) != 3 {
+			nonFatalError(invalidCommandFormat)
+		}
+		id, err := strconv.Atoi(args[2])
 		if err != nil {
-			fatalError(err)
+			nonFatalError(invalidCommandFormat)
 		}
-		if len(waitList) == 0 {
-			fmt.Println("No tasks to wait for now")
+
+		d, exists := getItemDetail(db, args[1], uint32(id))
+		if !exists {
+			nonFatalError(errors.New("No " + args[1] + " `" + args[2] + "` exists"))
 		}
-		for _, w := range waitList {
-			fmt.Println(fmt.Sprint("- ID: ", w.ID, "\n", w.Content))
+
+		if _, ok := flags["json"]; ok {
+			printItemJSON(d)
+		} else {
+			printItemDetail(d)
 		}
 
-	case "someday":
-		futureTasks, err := someday.GetActive(db)
+	case "process":
+		if len(args) != 2 {
+			nonFatalError(invalidCommandFormat)
+		}
+		captureID, err := strconv.Atoi(args[1])
 		if err != nil {
-			fatalError(err)
+			nonFatalError(invalidCommandFormat)
 		}
-		if len(futureTasks) == 0 {
-			fmt.Println("No tasks for someday")
+		exists, err := capture.IDExists(db, uint32(captureID))
+		if err != nil {
+			fatalError(err)
 		}
-		for _, s := range futureTasks {
-			fmt.Print(fmt.Sprint("- ID: ", s.ID, "\n", s.Content, "\n"))
+		if !exists {
+			nonFatalError(errors.New("Capture item `" + args[1] + "` does not exist"))
 		}
-
-	case "read":
-		taskList, err := tasks.Read(db)
+		c, err := capture.Get(db, uint32(captureID))
 		if err != nil {
 			fatalError(err)
 		}
+		content := strings.TrimSpace(c.Content)
 
-		if len(taskList) > 0 {
-			for _, t := range taskList {
-				fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
-				if t.ProjectID != nil {
-					project, err := projects.Get(db, *t.ProjectID)
-					if err != nil {
-						fatalError(err)
-					}
+		fmt.Println(content)
+		reader := bufio.NewReader(os.Stdin)
+		kind := promptOrFlag(reader, flags, "as", "Process into (next/project/wait/someday/trash): ")
 
-					fmt.Print(fmt.Sprint("Project: ", project.Content, "\n"))
-				}
-				if t.Context != nil {
-					fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
-				}
-				if t.Due != nil {
-					fmt.Print(fmt.Sprint("Due: ", *t.Due, "\n"))
+		title, _, _ := strings.Cut(content, "\n")
+		if kind != "trash" {
+			if t := promptOrFlag(reader, flags, "title", "Title (Press ENTER to use `"+title+"`): "); t != "" {
+				title = t
+			}
+		}
+
+		var table string
+		var id uint32
+		switch kind {
+		case "next":
+			contextList, err := contexts.GetAll(db)
+			if err != nil {
+				fatalError(err)
+			}
+			task := tasks.TudoTask{Content: title}
+			readTaskDetails(db, reader, flags, contextList, &task)
+			table = "tasks"
+			id, err = tasks.New(db, task)
+			if err != nil {
+				fatalError(err)
+			}
+		case "project":
+			exists, _, err := projects.ContentExists(db, title)
+			if err != nil {
+				fatalError(err)
+			}
+			if exists {
+				nonFatalError(errors.New("Project `" + title + "` already exists"))
+			}
+			areaList, err := areas.GetAll(db)
+			if err != nil {
+				fatalError(err)
+			}
+			project := projects.TudoProject{Content: title}
+			readProjectDetails(reader, flags, areaList, &project)
+			table = "projects"
+			id, err = projects.New(db, project)
+			if err != nil {
+				fatalError(err)
+			}
+		case "wait":
+			table = "waiting"
+			w := waiting.TudoWaiting{Content: title}
+			readWaitingDetails(db, reader, flags, &w)
+			id, err = waiting.New(db, w)
+			if err != nil {
+				fatalError(err)
+			}
+		case "someday":
+			table = "someday"
+			item := someday.TudoSomeday{Content: title}
+			if category := promptOrFlag(reader, flags, "category", "Category (e.g. books, trips) (Press ENTER to skip): "); category != "" {
+				item.Category = &category
+			}
+			id, err = someday.New(db, item)
+			if err != nil {
+				fatalError(err)
+			}
+		case "trash":
+		default:
+			nonFatalError(invalidCommand, kind)
+		}
+
+		if table != "" {
+			if content != title {
+				if err := notes.New(db, table, id, content); err != nil {
+					fatalError(err)
 				}
 			}
-		} else {
-			somedayTasks, err := someday.Read(db)
+			if err := notes.Move(db, "capture", c.ID, table, id); err != nil {
+				fatalError(err)
+			}
+			captureTags, err := tags.Get(db, "capture", c.ID)
 			if err != nil {
 				fatalError(err)
 			}
-			if len(somedayTasks) == 0 {
-				fmt.Println("Nothing to read for now")
-			} else {
-				for _, t := range somedayTasks {
-					fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
+			for _, tag := range captureTags {
+				if err := tags.Add(db, table, id, tag); err != nil {
+					fatalError(err)
 				}
 			}
+			applyTags(db, flags, table, id)
+			if err := log.NewAction(db, table, id, "created from capture "+args[1]); err != nil {
+				fatalError(err)
+			}
 		}
 
-	case "next":
-		tasks, err := tasks.GetActiveNextActions(db)
-		if err != nil {
+		if err := capture.Done(db, c.ID); err != nil {
+			fatalError(err)
+		}
+		if err := log.New(db, "capture", c.ID); err != nil {
 			fatalError(err)
 		}
+		fmt.Println("Processed capture item `" + args[1] + "`")
 
-		if len(tasks) == 0 {
-			fmt.Println("No next actions for now")
+	case "now":
+		available, err := contexts.Available(db, time.Now())
+		if err != nil {
+			fatalError(err)
 		}
 
-		for _, a := range tasks {
-			fmt.Print(fmt.Sprint("- ID: ", a.ID, "\n", a.Content, "\n"))
-			if a.Context != nil {
-				fmt.Print(fmt.Sprint("Context: ", *a.Context, "\n"))
+		var nowActions []tasks.TudoTask
+		for _, t := range filterTasksByTag(db, flags, actionableTasks(db)) {
+			if t.Context != nil {
+				if open, ok := available[*t.Context]; ok && !open {
+					continue
+				}
 			}
+			nowActions = append(nowActions, t)
+		}
+
+		nowActions = filterEffort(nowActions, flags)
+		sortTasks(db, nowActions, sortKey(flags, "priority", taskSortKeys...))
+
+		if len(nowActions) == 0 {
+			fmt.Println("Nothing to do right now")
 		}
 
+		printByContext(db, nowActions)
+
+	case "unschedule":
+		if len(args) != 2 {
+			nonFatalError(invalidCommandFormat)
+		}
+		name := strings.TrimPrefix(args[1], "@")
+		exists, contextID, err := contexts.ContentExists(db, name)
+		if err != nil {
+			fatalError(err)
+		}
+		if !exists {
+			nonFatalError(errors.New("No context `" + name + "` exists"))
+		}
+
+		if err := contexts.ClearSchedules(db, contextID); err != nil {
+			fatalError(err)
+		}
+		fmt.Println("Context `" + name + "` is now always available")
+
 	case "all":
+		key := sortKey(flags, "priority", taskSortKeys...)
 		if len(args) == 1 {
 			noTasks := true
 
@@ -704,41 +1876,29 @@ Commands:
 			if err != nil {
 				fatalError(err)
 			}
+			calendarTasks = filterTasksByTag(db, flags, calendarTasks)
 			if len(calendarTasks) > 0 {
 				noTasks = false
 			}
+			sortTasks(db, calendarTasks, key)
 			fmt.Println("CALENDAR")
 			for _, t := range calendarTasks {
-				fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
-				if t.ProjectID != nil {
-					project, err := projects.Get(db, *t.ProjectID)
-					if err != nil {
-						fatalError(err)
-					}
-					fmt.Print(fmt.Sprint("Project: ", project.Content, "\n"))
-				}
-				if t.Context != nil {
-					fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
-				}
-				if t.Due != nil {
-					fmt.Print(fmt.Sprint("Due: ", *t.Due, "\n"))
-				}
+				printTask(db, t, true)
 			}
 
 			nextActions, err := tasks.GetActiveNextActions(db)
 			if err != nil {
 				fatalError(err)
 			}
+			nextActions = filterTasksByTag(db, flags, nextActions)
 
 			if len(nextActions) > 0 {
 				noTasks = false
 				fmt.Println("\nNEXT ACTIONS")
 			}
+			sortTasks(db, nextActions, key)
 			for _, a := range nextActions {
-				fmt.Print(fmt.Sprint("- ID: ", a.ID, "\n", a.Content, "\n"))
-				if a.Context != nil {
-					fmt.Print(fmt.Sprint("Context: ", *a.Context, "\n"))
-				}
+				printTask(db, a, false)
 			}
 
 			fmt.Println("\nPROJECTS")
@@ -753,16 +1913,15 @@ Commands:
 				if err != nil {
 					fatalError(err)
 				}
+				projectTasks = filterTasksByTag(db, flags, projectTasks)
 
 				if len(projectTasks) > 0 {
 					noTasks = false
 					fmt.Println(project.Content)
 				}
+				sortTasks(db, projectTasks, key)
 				for _, t := range projectTasks {
-					fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
-					if t.Context != nil {
-						fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
-					}
+					printTask(db, t, false)
 				}
 			}
 
@@ -773,11 +1932,14 @@ Commands:
 				fatalError(err)
 			}
 
+			match := tagMatcher(db, flags, "waiting")
+			waitingTasks = slices.DeleteFunc(waitingTasks, func(w waiting.TudoWaiting) bool { return !match(w.ID) })
 			if len(waitingTasks) > 0 {
 				noTasks = false
 			}
 			for _, w := range waitingTasks {
 				fmt.Print(fmt.Sprint("- ID: ", w.ID, "\n", w.Content, "\n"))
+				printTags(db, "waiting", w.ID)
 			}
 
 			if noTasks {
@@ -803,28 +1965,20 @@ Commands:
 			if err != nil {
 				fatalError(err)
 			}
+			calendarTasks = filterTasksByTag(db, flags, calendarTasks)
 			nonCalendarTasks, err := tasks.GetActiveProjectTasks(db, projectID)
 			if err != nil {
 				fatalError(err)
 			}
+			nonCalendarTasks = filterTasksByTag(db, flags, nonCalendarTasks)
 
+			sortTasks(db, calendarTasks, key)
+			sortTasks(db, nonCalendarTasks, key)
 			for _, t := range calendarTasks {
-				fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
-				if t.Context != nil {
-					fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
-				}
-				if t.Due != nil {
-					fmt.Print(fmt.Sprint("Due: ", *t.Due, "\n"))
-				}
+				printTask(db, t, false)
 			}
 			for _, t := range nonCalendarTasks {
-				fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
-				if t.Context != nil {
-					fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
-				}
-				if t.Due != nil {
-					fmt.Print(fmt.Sprint("Due: ", *t.Due, "\n"))
-				}
+				printTask(db, t, false)
 			}
 		}
 
@@ -975,6 +2129,22 @@ Commands:
 			fmt.Print(fmt.Sprint("- ", p.Content, "\n"))
 		}
 
+		fmt.Println("\nSTALLED PROJECTS")
+		stalledProjects, err := projects.Stalled(db, idleDays(flags))
+		if err != nil {
+			fatalError(err)
+		}
+		printStalled(stalledProjects)
+
+		fmt.Println("\nAREAS WITHOUT ACTIVE PROJECTS")
+		idleAreas, err := areas.WithoutActiveProjects(db)
+		if err != nil {
+			fatalError(err)
+		}
+		for _, a := range idleAreas {
+			fmt.Print(fmt.Sprint("- ", a.Content, "\n"))
+		}
+
 		fmt.Println("\nMISSED CALENDAR TASKS")
 		pendingCalendarTasks, err := tasks.PendingCalendar(db, thresh)
 		if err != nil {
@@ -1034,23 +2204,101 @@ Commands:
 
 	default:
 		if len(args) == 1 && args[0] == "projects" {
-			projects, err := projects.GetActive(db)
+			_, all := flags["all"]
+			_, done := flags["done"]
+			_, archived := flags["archived"]
+
+			projectList, err := projects.GetAll(db)
+			if err != nil {
+				fatalError(err)
+			}
+			summaries, err := projects.Summaries(db)
 			if err != nil {
 				fatalError(err)
 			}
+			match := tagMatcher(db, flags, "projects")
+			var projects []projects.TudoProject
+			for _, p := range projectList {
+				if !match(p.ID) {
+					continue
+				}
+				switch {
+				case all,
+					done && p.Done && p.ArchivedAt == nil,
+					archived && p.ArchivedAt != nil,
+					!done && !archived && !p.Done:
+					projects = append(projects, p)
+				}
+			}
 			if len(projects) == 0 {
-				fmt.Println("No active projects")
+				fmt.Println("No matching projects")
+			}
+			switch sortKey(flags, "", "created", "project") {
+			case "created":
+				sort.SliceStable(projects, func(i, j int) bool { return projects[i].CreatedAt < projects[j].CreatedAt })
+			case "project":
+				sort.SliceStable(projects, func(i, j int) bool { return projects[i].Content < projects[j].Content })
 			}
 			for _, p := range projects {
 				fmt.Print(fmt.Sprint("- ID: ", p.ID, "\n", p.Content, "\n"))
+				if p.ArchivedAt != nil {
+					fmt.Println("Archived: " + *p.ArchivedAt)
+				}
+				if p.FinishedAt != nil {
+					fmt.Println("Finished: " + *p.FinishedAt)
+				}
+				printSummary(summaries[p.ID])
+				printTags(db, "projects", p.ID)
+			}
+		} else if len(args) == 1 && args[0] == "templates" {
+			dir := templateDir(dbFile)
+			names, err := templates.List(dir)
+			if err != nil {
+				fatalError(err)
+			}
+			if len(names) == 0 {
+				fmt.Println("No templates in `" + dir + "`")
+			}
+			for _, name := range names {
+				t, err := templates.Load(dir, name)
+				if err != nil {
+					fmt.Print(fmt.Sprint("- ", name, "\n", err.Error(), "\n"))
+					continue
+				}
+				fmt.Print(fmt.Sprint("- ", name, "\n", len(t.Tasks), " tasks\n"))
+			}
+		} else if len(args) == 1 && args[0] == "areas" {
+			areaList, err := areas.GetAll(db)
+			if err != nil {
+				fatalError(err)
+			}
+			activeProjects, err := projects.GetActive(db)
+			if err != nil {
+				fatalError(err)
+			}
+			if len(areaList) == 0 {
+				fmt.Println("No areas")
 			}
+			printAreas(areaList, activeProjects)
 		} else if len(args) == 1 && args[0] == "contexts" {
 			contextList, err := contexts.GetAll(db)
 			if err != nil {
 				fatalError(err)
 			}
+			if len(contextList) == 0 {
+				fmt.Println("No contexts")
+			}
 			for _, c := range contextList {
-				fmt.Print(fmt.Sprint("- ID: ", c.ID, "\n", c.Content, "\n"))
+				indent := strings.Repeat("  ", c.Depth)
+				fmt.Print(fmt.Sprint(indent, "- ID: ", c.ID, "\n", indent, c.Content, "\n"))
+
+				schedules, err := contexts.GetSchedules(db, c.ID)
+				if err != nil {
+					fatalError(err)
+				}
+				for _, sc := range schedules {
+					fmt.Print(fmt.Sprint(indent, "Available: ", sc.Weekdays, " ", sc.Start, "-", sc.End, "\n"))
+				}
 			}
 		} else {
 			projectName := ""
@@ -1072,27 +2320,38 @@ Commands:
 			if err != nil {
 				fatalError(err)
 			}
+			calendarTasks = filterTasksByTag(db, flags, calendarTasks)
 
 			nonCalendarTasks, err := tasks.GetActiveProjectTasks(db, projectID)
 			if err != nil {
 				fatalError(err)
 			}
+			nonCalendarTasks = filterTasksByTag(db, flags, nonCalendarTasks)
+
+			if exists {
+				printProjectSupport(db, projectID)
+
+				tree, err := projects.Tree(db, projectID)
+				if err != nil {
+					fatalError(err)
+				}
+				if len(tree) > 1 {
+					printProjectTree(tree)
+				}
+			}
 
 			if len(calendarTasks) == 0 && len(nonCalendarTasks) == 0 {
 				fmt.Println("No tasks for project `" + projectName + "` today")
 			}
+			// Without --sort the tasks keep the project's own order.
+			key := sortKey(flags, "", taskSortKeys...)
+			sortTasks(db, calendarTasks, key)
+			sortTasks(db, nonCalendarTasks, key)
 			for _, t := range calendarTasks {
-				fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
-				if t.Context != nil {
-					fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
-				}
-				fmt.Print(fmt.Sprint("Due: ", *t.Due, "\n"))
+				printTask(db, t, false)
 			}
 			for _, t := range nonCalendarTasks {
-				fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
-				if t.Context != nil {
-					fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
-				}
+				printTask(db, t, false)
 			}
 		}
 	}
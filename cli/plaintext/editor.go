@@ -0,0 +1,50 @@
+package plaintext
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// editText opens $EDITOR (vi when unset) on a temporary file holding
+// initial and returns the edited text with surrounding whitespace removed.
+func editText(initial string) string {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	f, err := os.CreateTemp("", "tudo-*.md")
+	if err != nil {
+		fatalError(err)
+	}
+	defer os.Remove(f.Name())
+	// The error helpers exit without running deferred calls.
+	fail := func(err error) {
+		os.Remove(f.Name())
+		fatalError(err)
+	}
+
+	if _, err := f.WriteString(initial); err != nil {
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fail(err)
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(f.Name())
+		nonFatalError(errors.New("Editor exited with error: " + err.Error()))
+	}
+
+	content, err := os.ReadFile(f.Name())
+	if err != nil {
+		fail(err)
+	}
+	return strings.TrimSpace(string(content))
+}
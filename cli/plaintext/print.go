@@ -0,0 +1,295 @@
+package plaintext
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"tudo/core/areas"
+	"tudo/core/checklist"
+	"tudo/core/contexts"
+	"tudo/core/notes"
+	"tudo/core/people"
+	"tudo/core/projects"
+	"tudo/core/reading"
+	"tudo/core/someday"
+	"tudo/core/tags"
+	"tudo/core/tasks"
+	"tudo/core/waiting"
+)
+
+func printContextTree(contextList []contexts.TudoContext) {
+	for _, c := range contextList {
+		fmt.Print(fmt.Sprint(strings.Repeat("  ", c.Depth), c.ID, ". ", c.Content, "\n"))
+	}
+}
+
+func printTask(db *sql.DB, t tasks.TudoTask, showProject bool) {
+	fmt.Print(fmt.Sprint("- ID: ", t.ID, "\n", t.Content, "\n"))
+	if t.Priority != nil {
+		fmt.Print(fmt.Sprint("Priority: ", *t.Priority, "\n"))
+	}
+	if showProject && t.ProjectID != nil {
+		project, err := projects.Get(db, *t.ProjectID)
+		if err != nil {
+			fatalError(err)
+		}
+		fmt.Print(fmt.Sprint("Project: ", project.Content, "\n"))
+	}
+	if t.Context != nil {
+		fmt.Print(fmt.Sprint("Context: ", *t.Context, "\n"))
+	}
+	if t.Due != nil {
+		fmt.Print(fmt.Sprint("Due: ", *t.Due, "\n"))
+	}
+	if t.Estimate != nil {
+		fmt.Print(fmt.Sprint("Estimate: ", tasks.FormatEstimate(*t.Estimate), "\n"))
+	}
+	if t.Energy != nil {
+		fmt.Print(fmt.Sprint("Energy: ", *t.Energy, "\n"))
+	}
+	if t.PersonID != nil {
+		person, err := people.Get(db, *t.PersonID)
+		if err != nil {
+			fatalError(err)
+		}
+		fmt.Print(fmt.Sprint("Discuss with: ", person.Name, "\n"))
+	}
+	blockers, err := tasks.GetBlockers(db, t.ID)
+	if err != nil {
+		fatalError(err)
+	}
+	if len(blockers) > 0 {
+		var ids []string
+		for _, b := range blockers {
+			ids = append(ids, fmt.Sprint(b.ID))
+		}
+		fmt.Print(fmt.Sprint("Blocked by: ", strings.Join(ids, ", "), "\n"))
+	}
+	done, total, err := checklist.Progress(db, t.ID)
+	if err != nil {
+		fatalError(err)
+	}
+	if total > 0 {
+		fmt.Print(fmt.Sprint("Checklist: ", done, "/", total, "\n"))
+	}
+	printTags(db, "tasks", t.ID)
+}
+
+func printTags(db *sql.DB, table string, rowID uint32) {
+	tagList, err := tags.Get(db, table, rowID)
+	if err != nil {
+		fatalError(err)
+	}
+	if len(tagList) > 0 {
+		fmt.Print(fmt.Sprint("Tags: #", strings.Join(tagList, " #"), "\n"))
+	}
+}
+
+// printByContext lists tasks under a heading per context, following the
+// order of the context tree, with tasks without a context listed last.
+func printByContext(db *sql.DB, taskList []tasks.TudoTask) {
+	byContext := make(map[string][]tasks.TudoTask)
+	var noContext []tasks.TudoTask
+	for _, t := range taskList {
+		if t.Context == nil {
+			noContext = append(noContext, t)
+			continue
+		}
+		byContext[*t.Context] = append(byContext[*t.Context], t)
+	}
+
+	contextList, err := contexts.GetAll(db)
+	if err != nil {
+		fatalError(err)
+	}
+
+	first := true
+	printGroup := func(heading string, group []tasks.TudoTask) {
+		if len(group) == 0 {
+			return
+		}
+		if !first {
+			fmt.Println()
+		}
+		first = false
+		fmt.Println(heading)
+		for _, t := range group {
+			printTask(db, t, true)
+		}
+	}
+
+	for _, c := range contextList {
+		printGroup("@"+c.Content, byContext[c.Content])
+		delete(byContext, c.Content)
+	}
+	// Tasks can still reference a context by a name that no longer exists.
+	var unknown []string
+	for name := range byContext {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		printGroup("@"+name, byContext[name])
+	}
+	printGroup("NO CONTEXT", noContext)
+}
+
+func printStalled(stalledList []projects.TudoStalled) {
+	for _, st := range stalledList {
+		fmt.Print(fmt.Sprint("- ID: ", st.Project.ID, "\n", st.Project.Content, "\n"))
+		fmt.Println("Stalled: " + strings.Join(st.Reasons, ", "))
+		fmt.Println("Last activity: " + st.LastActivity)
+	}
+}
+
+// printProjectSupport prints the support material of a project: its
+// outcome, purpose, reference material and notes.
+func printProjectSupport(db *sql.DB, projectID uint32) {
+	p, err := projects.Get(db, projectID)
+	if err != nil {
+		fatalError(err)
+	}
+	references, err := projects.GetReferences(db, projectID)
+	if err != nil {
+		fatalError(err)
+	}
+	noteList, err := notes.GetForItem(db, "projects", projectID)
+	if err != nil {
+		fatalError(err)
+	}
+	if p.Outcome == nil && p.Purpose == nil && len(references) == 0 && len(noteList) == 0 {
+		return
+	}
+
+	fmt.Println(strings.ToUpper(p.Content))
+	if p.Outcome != nil {
+		fmt.Println("Outcome: " + *p.Outcome)
+	}
+	if p.Purpose != nil {
+		fmt.Println("Purpose: " + *p.Purpose)
+	}
+	if len(references) > 0 {
+		fmt.Println("References:")
+		for _, r := range references {
+			fmt.Print(fmt.Sprint("  [", r.ID, "] ", r.Content, "\n"))
+		}
+	}
+	if len(noteList) > 0 {
+		fmt.Println("Notes:")
+		for _, n := range noteList {
+			fmt.Print(fmt.Sprint("  [", n.ID, "] ", n.CreatedAt, "\n"))
+			for _, line := range strings.Split(n.Content, "\n") {
+				fmt.Println("  " + line)
+			}
+		}
+	}
+	fmt.Println()
+}
+
+// printAreas prints the areas grouped by horizon, highest first, with the
+// active projects linked to each area and then the ones without an area.
+func printAreas(areaList []areas.TudoArea, projectList []projects.TudoProject) {
+	names := make(map[uint32]string)
+	for _, a := range areaList {
+		names[a.ID] = a.Content
+	}
+
+	horizon := 0
+	for _, a := range areaList {
+		if a.Horizon != horizon {
+			horizon = a.Horizon
+			fmt.Println(strings.ToUpper(areas.HorizonName(horizon)) + "S")
+		}
+		fmt.Print(fmt.Sprint("- ID: ", a.ID, "\n", a.Content, "\n"))
+		if a.ParentID != nil {
+			fmt.Println("Serves: " + names[*a.ParentID])
+		}
+		for _, p := range projectList {
+			if p.AreaID != nil && *p.AreaID == a.ID {
+				fmt.Print(fmt.Sprint("  - ", p.Content, " (", p.ID, ")\n"))
+			}
+		}
+	}
+
+	header := false
+	for _, p := range projectList {
+		if p.AreaID != nil {
+			continue
+		}
+		if !header {
+			fmt.Println("NO AREA")
+			header = true
+		}
+		fmt.Print(fmt.Sprint("  - ", p.Content, " (", p.ID, ")\n"))
+	}
+}
+
+// printProjectTree prints a project and its sub-projects with the task
+// counts of every subtree.
+func printProjectTree(tree []projects.TudoProjectNode) {
+	fmt.Println("SUB-PROJECTS")
+	for _, n := range tree {
+		status := ""
+		if n.Project.Done {
+			status = ", finished"
+		}
+		fmt.Print(fmt.Sprint(strings.Repeat("  ", n.Depth), "- ", n.Project.Content, " (", n.Project.ID, "): ",
+			n.Open, " open, ", n.Finished, " done", status, "\n"))
+	}
+	fmt.Println()
+}
+
+func printSummary(s projects.TudoSummary) {
+	fmt.Print(fmt.Sprint("Tasks: ", s.Open, " open, ", s.Finished, " done (", s.Percent(), "%)\n"))
+	if s.Overdue > 0 {
+		fmt.Print(fmt.Sprint("Overdue: ", s.Overdue, "\n"))
+	}
+	if s.NextDue != nil {
+		fmt.Println("Next due: " + *s.NextDue)
+	}
+	fmt.Print(fmt.Sprint("Last activity: ", s.LastActivity, ", age ", s.AgeDays, " days\n"))
+}
+
+func printWaiting(w waiting.TudoWaiting) {
+	fmt.Print(fmt.Sprint("- ID: ", w.ID, "\n", w.Content, "\n"))
+	if w.Delegate != nil {
+		fmt.Println("Waiting on: " + *w.Delegate)
+	}
+	if w.DelegatedAt != nil {
+		fmt.Println("Delegated: " + *w.DelegatedAt)
+	}
+	if w.ExpectedBy != nil {
+		fmt.Println("Expected by: " + *w.ExpectedBy)
+	}
+	if w.FollowUp != nil {
+		fmt.Println("Follow up: " + *w.FollowUp)
+	}
+	if w.Context != nil {
+		fmt.Println("Context: " + *w.Context)
+	}
+}
+
+func printReading(r reading.TudoReading) {
+	fmt.Print(fmt.Sprint("- ID: ", r.ID, "\n", r.Title, "\n"))
+	if r.URL != nil {
+		fmt.Println("URL: " + *r.URL)
+	}
+	if r.Source != nil {
+		fmt.Println("Source: " + *r.Source)
+	}
+	if r.Minutes != nil {
+		fmt.Println("Reading time: " + tasks.FormatEstimate(*r.Minutes))
+	}
+}
+
+func printSomeday(s someday.TudoSomeday) {
+	fmt.Print(fmt.Sprint("- ID: ", s.ID, "\n", s.Content, "\n"))
+	if s.Category != nil {
+		fmt.Println("Category: " + *s.Category)
+	}
+	if s.ReviewedAt != nil {
+		fmt.Println("Reviewed: " + *s.ReviewedAt)
+	}
+}
@@ -0,0 +1,484 @@
+package plaintext
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tudo/core/areas"
+	"tudo/core/contexts"
+	"tudo/core/people"
+	"tudo/core/projects"
+	"tudo/core/reading"
+	"tudo/core/tasks"
+	"tudo/core/waiting"
+)
+
+// promptOrFlag returns the value of the flag when it was given on the
+// command line and otherwise asks for it on stdin.
+func promptOrFlag(reader *bufio.Reader, flags map[string]string, name, prompt string) string {
+	if value, ok := flags[name]; ok {
+		return strings.TrimSpace(value)
+	}
+	fmt.Print(prompt)
+	value, _ := reader.ReadString('\n')
+	return strings.TrimSpace(value)
+}
+
+func validateDate(dateStr string) {
+	if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+		nonFatalError(err)
+	}
+}
+
+func validateDue(dueStr string) {
+	validateDate(dueStr)
+	due, _ := time.Parse("2006-01-02", dueStr)
+	yyyy, mm, dd := time.Now().Date()
+	if due.Before(time.Date(yyyy, mm, dd, 0, 0, 0, 0, time.UTC)) {
+		nonFatalError(errors.New("due date has passed already"))
+	}
+}
+
+// readTaskDetails fills in the optional fields of a new task from flags,
+// prompting for the ones that were not given.
+func readTaskDetails(db *sql.DB, reader *bufio.Reader, flags map[string]string, contextList []contexts.TudoContext, task *tasks.TudoTask) {
+	dueStr := promptOrFlag(reader, flags, "due", "Due date (YYYY-MM-DD) (Press ENTER if no due date): ")
+	if dueStr != "" {
+		validateDue(dueStr)
+		task.Due = &dueStr
+	}
+
+	if name, ok := flags["context"]; ok {
+		task.Context = contextByName(contextList, name)
+	} else {
+		fmt.Println("Select context (Press ENTER if no context): ")
+		printContextTree(contextList)
+		number, _ := reader.ReadString('\n')
+		task.Context = contextByNumber(contextList, strings.TrimSpace(number))
+	}
+
+	estimateStr := promptOrFlag(reader, flags, "estimate", "Estimate (e.g. 15m, 1h) (Press ENTER if no estimate): ")
+	if estimateStr != "" {
+		estimate, err := tasks.ParseEstimate(estimateStr)
+		if err != nil {
+			nonFatalError(err)
+		}
+		task.Estimate = &estimate
+	}
+
+	energyStr := promptOrFlag(reader, flags, "energy", "Energy (low/medium/high) (Press ENTER if not set): ")
+	if energyStr != "" {
+		energy, err := tasks.ParseEnergy(energyStr)
+		if err != nil {
+			nonFatalError(err)
+		}
+		task.Energy = &energy
+	}
+
+	priorityStr := promptOrFlag(reader, flags, "priority", "Priority (A-D) (Press ENTER if no priority): ")
+	if priorityStr != "" {
+		priority, err := tasks.ParsePriority(priorityStr)
+		if err != nil {
+			nonFatalError(err)
+		}
+		task.Priority = &priority
+	}
+
+	task.PersonID = readPerson(db, reader, flags, "person", "Discuss with (Press ENTER to skip): ")
+}
+
+// editValue returns the new value of a field and whether it changes. With
+// no flags given it prompts showing the current value, where ENTER keeps
+// it, and otherwise it takes the value of the flag if present.
+func editValue(reader *bufio.Reader, flags map[string]string, name, prompt string, current *string) (string, bool) {
+	if len(flags) > 0 {
+		v, ok := flags[name]
+		return strings.TrimSpace(v), ok
+	}
+	cur := "none"
+	if current != nil {
+		cur = *current
+	}
+	fmt.Print(prompt + " [" + cur + "]: ")
+	v, _ := reader.ReadString('\n')
+	v = strings.TrimSpace(v)
+	return v, v != ""
+}
+
+// editTaskDetails updates the editable fields of a task from flags, or
+// prompts for every field when no flags were given. At a prompt ENTER keeps
+// the current value and `-` clears it.
+func editTaskDetails(db *sql.DB, reader *bufio.Reader, flags map[string]string, contextList []contexts.TudoContext, task *tasks.TudoTask) {
+	interactive := len(flags) == 0
+	value := func(name, prompt string, current *string) (string, bool) {
+		return editValue(reader, flags, name, prompt, current)
+	}
+
+	if v, ok := value("content", "Content", &task.Content); ok && v != "-" {
+		task.Content = v
+	}
+
+	if v, ok := value("due", "Due date (YYYY-MM-DD)", task.Due); ok {
+		if v == "-" || v == "" {
+			task.Due = nil
+		} else {
+			validateDue(v)
+			task.Due = &v
+		}
+	}
+
+	if interactive {
+		printContextTree(contextList)
+	}
+	if v, ok := value("context", "Context", task.Context); ok {
+		if v == "-" || v == "" {
+			task.Context = nil
+		} else if interactive {
+			task.Context = contextByNumber(contextList, v)
+		} else {
+			task.Context = contextByName(contextList, v)
+		}
+	}
+
+	var estimate *string
+	if task.Estimate != nil {
+		e := tasks.FormatEstimate(*task.Estimate)
+		estimate = &e
+	}
+	if v, ok := value("estimate", "Estimate (e.g. 15m, 1h)", estimate); ok {
+		if v == "-" || v == "" {
+			task.Estimate = nil
+		} else {
+			e, err := tasks.ParseEstimate(v)
+			if err != nil {
+				nonFatalError(err)
+			}
+			task.Estimate = &e
+		}
+	}
+
+	if v, ok := value("energy", "Energy (low/medium/high)", task.Energy); ok {
+		if v == "-" || v == "" {
+			task.Energy = nil
+		} else {
+			e, err := tasks.ParseEnergy(v)
+			if err != nil {
+				nonFatalError(err)
+			}
+			task.Energy = &e
+		}
+	}
+
+	if v, ok := value("priority", "Priority (A-D)", task.Priority); ok {
+		if v == "-" || v == "" {
+			task.Priority = nil
+		} else {
+			p, err := tasks.ParsePriority(v)
+			if err != nil {
+				nonFatalError(err)
+			}
+			task.Priority = &p
+		}
+	}
+
+	task.PersonID = editPerson(db, reader, flags, "person", "Discuss with", task.PersonID)
+}
+
+// readProjectDetails fills in the optional fields of a new project from
+// flags, prompting for the ones that were not given.
+func readProjectDetails(reader *bufio.Reader, flags map[string]string, areaList []areas.TudoArea, project *projects.TudoProject) {
+	switch promptOrFlag(reader, flags, "order", "Order (sequential/parallel) (Press ENTER for parallel): ") {
+	case "", "parallel":
+		project.Sequential = false
+	case "sequential":
+		project.Sequential = true
+	default:
+		nonFatalError(errors.New("Invalid order, expected sequential or parallel"))
+	}
+
+	if outcome := promptOrFlag(reader, flags, "outcome", "Desired outcome (Press ENTER to skip): "); outcome != "" {
+		project.Outcome = &outcome
+	}
+	if purpose := promptOrFlag(reader, flags, "purpose", "Purpose (Press ENTER to skip): "); purpose != "" {
+		project.Purpose = &purpose
+	}
+
+	// Without any areas there is nothing to link the project to; goals and
+	// higher horizons do not hold projects.
+	hasAreas := slices.ContainsFunc(areaList, func(a areas.TudoArea) bool { return a.Horizon == areas.HorizonArea })
+	if _, ok := flags["area"]; ok || hasAreas {
+		project.AreaID = areaByName(areaList, promptOrFlag(reader, flags, "area", "Area of responsibility (Press ENTER to skip): "))
+	}
+}
+
+// editProjectDetails updates the editable fields of a project like
+// editTaskDetails does for tasks.
+func editProjectDetails(reader *bufio.Reader, flags map[string]string, areaList []areas.TudoArea, project *projects.TudoProject) {
+	order := "parallel"
+	if project.Sequential {
+		order = "sequential"
+	}
+	if v, ok := editValue(reader, flags, "order", "Order (sequential/parallel)", &order); ok {
+		switch v {
+		case "parallel":
+			project.Sequential = false
+		case "sequential":
+			project.Sequential = true
+		default:
+			nonFatalError(errors.New("Invalid order, expected sequential or parallel"))
+		}
+	}
+
+	if v, ok := editValue(reader, flags, "outcome", "Desired outcome", project.Outcome); ok {
+		if v == "-" || v == "" {
+			project.Outcome = nil
+		} else {
+			project.Outcome = &v
+		}
+	}
+
+	if v, ok := editValue(reader, flags, "purpose", "Purpose", project.Purpose); ok {
+		if v == "-" || v == "" {
+			project.Purpose = nil
+		} else {
+			project.Purpose = &v
+		}
+	}
+
+	var area *string
+	for _, a := range areaList {
+		if project.AreaID != nil && a.ID == *project.AreaID {
+			area = &a.Content
+		}
+	}
+	if v, ok := editValue(reader, flags, "area", "Area of responsibility", area); ok {
+		if v == "-" {
+			v = ""
+		}
+		project.AreaID = areaByName(areaList, v)
+	}
+}
+
+func contextByNumber(contextList []contexts.TudoContext, number string) *string {
+	if number == "" {
+		return nil
+	}
+	id, err := strconv.Atoi(number)
+	if err != nil {
+		nonFatalError(invalidCommandFormat)
+	}
+	for _, c := range contextList {
+		if c.ID == uint32(id) {
+			return &c.Content
+		}
+	}
+	nonFatalError(errors.New("No context `" + number + "` exists"))
+	return nil
+}
+
+func contextByName(contextList []contexts.TudoContext, name string) *string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+	if name == "" {
+		return nil
+	}
+	for _, c := range contextList {
+		if c.Content == name {
+			return &c.Content
+		}
+	}
+	nonFatalError(errors.New("No context `" + name + "` exists"))
+	return nil
+}
+
+func areaByName(areaList []areas.TudoArea, name string) *uint32 {
+	if name == "" {
+		return nil
+	}
+	for _, a := range areaList {
+		if a.Content != name {
+			continue
+		}
+		if a.Horizon != areas.HorizonArea {
+			nonFatalError(errors.New("`" + name + "` is a " + areas.HorizonName(a.Horizon) + ", only areas can hold projects"))
+		}
+		return &a.ID
+	}
+	nonFatalError(errors.New("No area `" + name + "` exists"))
+	return nil
+}
+
+// readResolution asks what to do with the open tasks of a project that is
+// being finished, or takes it from --tasks and --to.
+func readResolution(db *sql.DB, reader *bufio.Reader, flags map[string]string) projects.TudoResolution {
+	var resolution projects.TudoResolution
+	switch promptOrFlag(reader, flags, "tasks", "Complete (c), move to another project (m), make next actions (n) or cancel (x) them? ") {
+	case "c", projects.TasksComplete:
+		resolution.Action = projects.TasksComplete
+	case "m", projects.TasksMove:
+		resolution.Action = projects.TasksMove
+		target := promptOrFlag(reader, flags, "to", "Move to project (name or id): ")
+		resolution.ProjectID, _ = lookupProject(db, strings.Fields(target))
+	case "n", projects.TasksNext:
+		resolution.Action = projects.TasksNext
+	case "x", projects.TasksCancel:
+		resolution.Action = projects.TasksCancel
+	default:
+		nonFatalError(errors.New("Project not finished, expected complete, move, next or cancel"))
+	}
+	return resolution
+}
+
+// readReadingDetails fills in the optional fields of a reading list item
+// from flags or prompts.
+func readReadingDetails(reader *bufio.Reader, flags map[string]string, r *reading.TudoReading) {
+	if url := promptOrFlag(reader, flags, "url", "URL (Press ENTER to skip): "); url != "" {
+		r.URL = &url
+	}
+	if source := promptOrFlag(reader, flags, "source", "Source (e.g. book, blog, newsletter) (Press ENTER to skip): "); source != "" {
+		r.Source = &source
+	}
+	if timeStr := promptOrFlag(reader, flags, "time", "Reading time (e.g. 15m, 1h) (Press ENTER if unknown): "); timeStr != "" {
+		minutes, err := tasks.ParseEstimate(timeStr)
+		if err != nil {
+			nonFatalError(err)
+		}
+		r.Minutes = &minutes
+	}
+}
+
+// readWaitingDetails fills in the optional fields of a new waiting-for item
+// from flags, prompting for the ones that were not given.
+func readWaitingDetails(db *sql.DB, reader *bufio.Reader, flags map[string]string, w *waiting.TudoWaiting) {
+	w.PersonID = readPerson(db, reader, flags, "delegate", "Waiting on (Press ENTER to skip): ")
+
+	dates := []struct {
+		flag, prompt string
+		field        **string
+	}{
+		{"delegated", "Delegated on (YYYY-MM-DD) (Press ENTER for today): ", &w.DelegatedAt},
+		{"expected", "Expected by (YYYY-MM-DD) (Press ENTER to skip): ", &w.ExpectedBy},
+		{"follow-up", "Follow up on (YYYY-MM-DD) (Press ENTER to skip): ", &w.FollowUp},
+	}
+	for _, d := range dates {
+		if v := promptOrFlag(reader, flags, d.flag, d.prompt); v != "" {
+			validateDate(v)
+			*d.field = &v
+		}
+	}
+}
+
+// editWaitingDetails updates the editable fields of a waiting-for item like
+// editTaskDetails does for tasks.
+func editWaitingDetails(db *sql.DB, reader *bufio.Reader, flags map[string]string, w *waiting.TudoWaiting) {
+	if v, ok := editValue(reader, flags, "content", "Content", &w.Content); ok && v != "-" {
+		w.Content = v
+	}
+
+	w.PersonID = editPerson(db, reader, flags, "delegate", "Waiting on", w.PersonID)
+
+	fields := []struct {
+		flag, prompt string
+		field        **string
+		date         bool
+	}{
+		{"delegated", "Delegated on (YYYY-MM-DD)", &w.DelegatedAt, true},
+		{"expected", "Expected by (YYYY-MM-DD)", &w.ExpectedBy, true},
+		{"follow-up", "Follow up on (YYYY-MM-DD)", &w.FollowUp, true},
+	}
+	for _, f := range fields {
+		v, ok := editValue(reader, flags, f.flag, f.prompt, *f.field)
+		if !ok {
+			continue
+		}
+		if v == "-" || v == "" {
+			*f.field = nil
+			continue
+		}
+		if f.date {
+			validateDate(v)
+		}
+		*f.field = &v
+	}
+}
+
+// readPerson asks for a person by number or name out of the people list, or
+// takes the name from the flag. Without any people nothing is asked.
+func readPerson(db *sql.DB, reader *bufio.Reader, flags map[string]string, name, prompt string) *uint32 {
+	peopleList, err := people.GetAll(db)
+	if err != nil {
+		fatalError(err)
+	}
+	if _, ok := flags[name]; !ok {
+		if len(peopleList) == 0 {
+			return nil
+		}
+		printPeople(peopleList)
+	}
+	return personByInput(db, peopleList, promptOrFlag(reader, flags, name, prompt))
+}
+
+// editPerson is editValue for a linked person, shown and given by name or
+// number.
+func editPerson(db *sql.DB, reader *bufio.Reader, flags map[string]string, name, prompt string, current *uint32) *uint32 {
+	peopleList, err := people.GetAll(db)
+	if err != nil {
+		fatalError(err)
+	}
+	var currentName *string
+	for _, p := range peopleList {
+		if current != nil && p.ID == *current {
+			currentName = &p.Name
+		}
+	}
+	if len(flags) == 0 {
+		if len(peopleList) == 0 {
+			return current
+		}
+		printPeople(peopleList)
+	}
+	v, ok := editValue(reader, flags, name, prompt, currentName)
+	if !ok {
+		return current
+	}
+	if v == "-" {
+		return nil
+	}
+	return personByInput(db, peopleList, v)
+}
+
+func printPeople(peopleList []people.TudoPerson) {
+	for _, p := range peopleList {
+		fmt.Print(fmt.Sprint(p.ID, ". ", p.Name, "\n"))
+	}
+}
+
+// personByInput resolves a person by number or name. A name not seen before
+// adds the person, so that anyone can be delegated to without setting them
+// up first.
+func personByInput(db *sql.DB, peopleList []people.TudoPerson, input string) *uint32 {
+	if input == "" {
+		return nil
+	}
+	id, err := strconv.Atoi(input)
+	for _, p := range peopleList {
+		if (err == nil && p.ID == uint32(id)) || p.Name == input {
+			return &p.ID
+		}
+	}
+	if err == nil {
+		nonFatalError(errors.New(fmt.Sprint("No person `", id, "` exists")))
+	}
+
+	newID, err := people.New(db, input)
+	if err != nil {
+		fatalError(err)
+	}
+	fmt.Println("Added person `" + input + "`")
+	return &newID
+}
@@ -0,0 +1,95 @@
+package plaintext
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"tudo/core/log"
+	"tudo/core/move"
+	"tudo/core/projects"
+	"tudo/core/someday"
+)
+
+// reviewSomeday walks through the someday items not reviewed in the last
+// --weeks weeks, asking for each whether to keep, delete, activate or
+// promote it.
+func reviewSomeday(db *sql.DB, flags map[string]string) {
+	weeks := 4
+	if weeksStr, ok := flags["weeks"]; ok {
+		var err error
+		weeks, err = strconv.Atoi(weeksStr)
+		if err != nil || weeks < 0 {
+			nonFatalError(errors.New("Invalid number of weeks `" + weeksStr + "`"))
+		}
+	}
+
+	items, err := someday.DueForReview(db, weeks)
+	if err != nil {
+		fatalError(err)
+	}
+	if len(items) == 0 {
+		fmt.Println("No someday items to review")
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for i, item := range items {
+		fmt.Print(fmt.Sprint("\n[", i+1, "/", len(items), "]\n"))
+		printSomeday(item)
+		printTags(db, "someday", item.ID)
+
+		fmt.Print("Keep (k), delete (d), activate as next action (a), promote to project (p), skip (s) or quit (q)? ")
+		ans, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(ans) {
+		case "k":
+			if err := someday.Reviewed(db, item.ID); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Kept")
+		case "d":
+			if err := someday.Delete(db, item.ID); err != nil {
+				fatalError(err)
+			}
+			fmt.Println("Deleted")
+		case "a":
+			id, err := move.Item(db, "someday", item.ID, move.TudoDestination{Table: "tasks"})
+			if err != nil {
+				fatalError(err)
+			}
+			if err := log.NewAction(db, "tasks", id, fmt.Sprint("moved from someday ", item.ID)); err != nil {
+				fatalError(err)
+			}
+			fmt.Print(fmt.Sprint("Activated as next action `", id, "`\n"))
+		case "p":
+			promoteSomeday(db, item)
+		case "s", "":
+		case "q":
+			return
+		default:
+			fmt.Println("Skipped, expected k, d, a, p, s or q")
+		}
+	}
+}
+
+// promoteSomeday turns a someday item into a project of the same name,
+// carrying its notes and tags over.
+func promoteSomeday(db *sql.DB, item someday.TudoSomeday) {
+	exists, _, err := projects.ContentExists(db, item.Content)
+	if err != nil {
+		fatalError(err)
+	}
+	if exists {
+		fmt.Println("Project `" + item.Content + "` already exists, skipped")
+		return
+	}
+
+	if _, err := someday.Promote(db, item.ID); err != nil {
+		fatalError(err)
+	}
+	fmt.Println("Project `" + item.Content + "` has been created")
+}
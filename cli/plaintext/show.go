@@ -0,0 +1,337 @@
+package plaintext
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"tudo/core/areas"
+	"tudo/core/capture"
+	"tudo/core/checklist"
+	"tudo/core/log"
+	"tudo/core/notes"
+	"tudo/core/people"
+	"tudo/core/projects"
+	"tudo/core/reading"
+	"tudo/core/someday"
+	"tudo/core/tags"
+	"tudo/core/tasks"
+	"tudo/core/waiting"
+)
+
+type noteDetail struct {
+	ID        uint32 `json:"id"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+type checklistDetail struct {
+	ID      uint32 `json:"id"`
+	Content string `json:"content"`
+	Done    bool   `json:"done"`
+}
+
+type referenceDetail struct {
+	ID      uint32 `json:"id"`
+	Content string `json:"content"`
+}
+
+type historyDetail struct {
+	Action string `json:"action"`
+	At     string `json:"at"`
+}
+
+// itemDetail holds every field of a single item for `tudo show`. Fields that
+// do not apply to the item's type are left empty.
+type itemDetail struct {
+	Type        string            `json:"type"`
+	ID          uint32            `json:"id"`
+	Content     string            `json:"content"`
+	Done        bool              `json:"done"`
+	Project     *string           `json:"project,omitempty"`
+	Context     *string           `json:"context,omitempty"`
+	Due         *string           `json:"due,omitempty"`
+	Order       *string           `json:"order,omitempty"`
+	Parent      *string           `json:"parent,omitempty"`
+	Area        *string           `json:"area,omitempty"`
+	URL         *string           `json:"url,omitempty"`
+	Source      *string           `json:"source,omitempty"`
+	Category    *string           `json:"category,omitempty"`
+	ReviewedAt  *string           `json:"reviewed_at,omitempty"`
+	Person      *string           `json:"person,omitempty"`
+	Delegate    *string           `json:"delegate,omitempty"`
+	DelegatedAt *string           `json:"delegated_at,omitempty"`
+	ExpectedBy  *string           `json:"expected_by,omitempty"`
+	FollowUp    *string           `json:"follow_up,omitempty"`
+	Outcome     *string           `json:"outcome,omitempty"`
+	Purpose     *string           `json:"purpose,omitempty"`
+	References  []referenceDetail `json:"references,omitempty"`
+	Position    *uint32           `json:"position,omitempty"`
+	Priority    *string           `json:"priority,omitempty"`
+	Estimate    *string           `json:"estimate,omitempty"`
+	ReadingTime *string           `json:"reading_time,omitempty"`
+	Energy      *string           `json:"energy,omitempty"`
+	CreatedAt   string            `json:"created_at"`
+	FinishedAt  *string           `json:"finished_at,omitempty"`
+	ArchivedAt  *string           `json:"archived_at,omitempty"`
+	Cancelled   bool              `json:"cancelled,omitempty"`
+	BlockedBy   []uint32          `json:"blocked_by,omitempty"`
+	Checklist   []checklistDetail `json:"checklist,omitempty"`
+	Tags        []string          `json:"tags"`
+	Notes       []noteDetail      `json:"notes"`
+	History     []historyDetail   `json:"history"`
+}
+
+// getItemDetail loads an item of the given command line type, returning
+// false when it does not exist.
+func getItemDetail(db *sql.DB, kind string, id uint32) (itemDetail, bool) {
+	d := itemDetail{Type: kind, ID: id}
+	var err error
+	switch kind {
+	case "task":
+		var t tasks.TudoTask
+		t, err = tasks.Get(db, id)
+		d.Content, d.Done, d.CreatedAt, d.FinishedAt = t.Content, t.Done, t.CreatedAt, t.FinishedAt
+		d.Cancelled = t.Cancelled
+		if err == nil && t.PersonID != nil {
+			var person people.TudoPerson
+			person, err = people.Get(db, *t.PersonID)
+			d.Person = &person.Name
+		}
+		d.Context, d.Due, d.Priority, d.Energy, d.Position = t.Context, t.Due, t.Priority, t.Energy, t.Position
+		if t.Estimate != nil {
+			e := tasks.FormatEstimate(*t.Estimate)
+			d.Estimate = &e
+		}
+		if err == nil && t.ProjectID != nil {
+			var p projects.TudoProject
+			p, err = projects.Get(db, *t.ProjectID)
+			d.Project = &p.Content
+		}
+		if err == nil {
+			var blockers []tasks.TudoTask
+			blockers, err = tasks.GetBlockers(db, id)
+			for _, b := range blockers {
+				d.BlockedBy = append(d.BlockedBy, b.ID)
+			}
+		}
+		if err == nil {
+			var items []checklist.TudoChecklistItem
+			items, err = checklist.GetForTask(db, id)
+			for _, c := range items {
+				d.Checklist = append(d.Checklist, checklistDetail{ID: c.ID, Content: c.Content, Done: c.Done})
+			}
+		}
+	case "project":
+		var p projects.TudoProject
+		p, err = projects.Get(db, id)
+		d.Content, d.Done, d.CreatedAt, d.FinishedAt = p.Content, p.Done, p.CreatedAt, p.FinishedAt
+		d.ArchivedAt = p.ArchivedAt
+		order := "parallel"
+		if p.Sequential {
+			order = "sequential"
+		}
+		d.Order = &order
+		d.Outcome, d.Purpose = p.Outcome, p.Purpose
+		if err == nil && p.ParentID != nil {
+			var parent projects.TudoProject
+			parent, err = projects.Get(db, *p.ParentID)
+			d.Parent = &parent.Content
+		}
+		if err == nil && p.AreaID != nil {
+			var a areas.TudoArea
+			a, err = areas.Get(db, *p.AreaID)
+			d.Area = &a.Content
+		}
+		if err == nil {
+			var references []projects.TudoReference
+			references, err = projects.GetReferences(db, id)
+			for _, r := range references {
+				d.References = append(d.References, referenceDetail{ID: r.ID, Content: r.Content})
+			}
+		}
+	case "waiting":
+		var w waiting.TudoWaiting
+		w, err = waiting.Get(db, id)
+		d.Content, d.Done, d.CreatedAt, d.FinishedAt = w.Content, w.Done, w.CreatedAt, w.FinishedAt
+		d.Delegate, d.DelegatedAt, d.ExpectedBy, d.FollowUp = w.Delegate, w.DelegatedAt, w.ExpectedBy, w.FollowUp
+		d.Context = w.Context
+		if err == nil && w.ProjectID != nil {
+			var p projects.TudoProject
+			p, err = projects.Get(db, *w.ProjectID)
+			d.Project = &p.Content
+		}
+	case "someday":
+		var s someday.TudoSomeday
+		s, err = someday.Get(db, id)
+		d.Content, d.Done, d.CreatedAt = s.Content, s.Done, s.CreatedAt
+		d.Category, d.ReviewedAt = s.Category, s.ReviewedAt
+	case "read":
+		var r reading.TudoReading
+		r, err = reading.Get(db, id)
+		d.Content, d.Done, d.CreatedAt, d.FinishedAt = r.Title, r.Done, r.CreatedAt, r.ReadAt
+		d.URL, d.Source = r.URL, r.Source
+		if r.Minutes != nil {
+			t := tasks.FormatEstimate(*r.Minutes)
+			d.ReadingTime = &t
+		}
+	case "in":
+		var c capture.TudoCapture
+		c, err = capture.Get(db, id)
+		d.Content, d.Done, d.CreatedAt = strings.TrimSpace(c.Content), c.Done, c.CreatedAt
+	default:
+		nonFatalError(invalidCommand, kind)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return itemDetail{}, false
+	} else if err != nil {
+		fatalError(err)
+	}
+
+	table := itemTable(kind)
+	d.Tags, err = tags.Get(db, table, id)
+	if err != nil {
+		fatalError(err)
+	}
+
+	noteList, err := notes.GetForItem(db, table, id)
+	if err != nil {
+		fatalError(err)
+	}
+	d.Notes = []noteDetail{}
+	for _, n := range noteList {
+		d.Notes = append(d.Notes, noteDetail{ID: n.ID, Content: n.Content, CreatedAt: n.CreatedAt})
+	}
+
+	history, err := log.History(db, table, id)
+	if err != nil {
+		fatalError(err)
+	}
+	d.History = []historyDetail{}
+	for _, a := range history {
+		d.History = append(d.History, historyDetail{Action: a.Action, At: a.CreatedAt})
+	}
+
+	if d.Tags == nil {
+		d.Tags = []string{}
+	}
+	return d, true
+}
+
+func printItemDetail(d itemDetail) {
+	fmt.Print(fmt.Sprint("- ID: ", d.ID, "\n", d.Content, "\n"))
+	fmt.Println("Type: " + d.Type)
+	if d.ArchivedAt != nil {
+		fmt.Println("Status: archived")
+	} else if d.Cancelled {
+		fmt.Println("Status: cancelled")
+	} else if d.Done {
+		fmt.Println("Status: done")
+	} else {
+		fmt.Println("Status: open")
+	}
+
+	optional := []struct {
+		label string
+		value *string
+	}{
+		{"Project", d.Project},
+		{"Order", d.Order},
+		{"Parent", d.Parent},
+		{"Area", d.Area},
+		{"URL", d.URL},
+		{"Source", d.Source},
+		{"Category", d.Category},
+		{"Reviewed", d.ReviewedAt},
+		{"Discuss with", d.Person},
+		{"Waiting on", d.Delegate},
+		{"Delegated", d.DelegatedAt},
+		{"Expected by", d.ExpectedBy},
+		{"Follow up", d.FollowUp},
+		{"Outcome", d.Outcome},
+		{"Purpose", d.Purpose},
+		{"Context", d.Context},
+		{"Due", d.Due},
+		{"Priority", d.Priority},
+		{"Estimate", d.Estimate},
+		{"Reading time", d.ReadingTime},
+		{"Energy", d.Energy},
+	}
+	for _, o := range optional {
+		if o.value != nil {
+			fmt.Println(o.label + ": " + *o.value)
+		}
+	}
+
+	if len(d.References) > 0 {
+		fmt.Println("References:")
+		for _, r := range d.References {
+			fmt.Print(fmt.Sprint("  [", r.ID, "] ", r.Content, "\n"))
+		}
+	}
+	if d.Position != nil {
+		fmt.Print(fmt.Sprint("Position: ", *d.Position, "\n"))
+	}
+	if len(d.BlockedBy) > 0 {
+		var ids []string
+		for _, id := range d.BlockedBy {
+			ids = append(ids, fmt.Sprint(id))
+		}
+		fmt.Println("Blocked by: " + strings.Join(ids, ", "))
+	}
+
+	if len(d.Checklist) > 0 {
+		checked := 0
+		for _, c := range d.Checklist {
+			if c.Done {
+				checked++
+			}
+		}
+		fmt.Print(fmt.Sprint("Checklist: ", checked, "/", len(d.Checklist), "\n"))
+		for _, c := range d.Checklist {
+			mark := " "
+			if c.Done {
+				mark = "x"
+			}
+			fmt.Print(fmt.Sprint("  [", mark, "] ", c.ID, ". ", c.Content, "\n"))
+		}
+	}
+
+	if len(d.Tags) > 0 {
+		fmt.Println("Tags: #" + strings.Join(d.Tags, " #"))
+	}
+	fmt.Println("Created: " + d.CreatedAt)
+	if d.FinishedAt != nil {
+		fmt.Println("Finished: " + *d.FinishedAt)
+	}
+	if d.ArchivedAt != nil {
+		fmt.Println("Archived: " + *d.ArchivedAt)
+	}
+
+	if len(d.Notes) > 0 {
+		fmt.Println("Notes:")
+		for _, n := range d.Notes {
+			fmt.Print(fmt.Sprint("  [", n.ID, "] ", n.CreatedAt, "\n"))
+			for _, line := range strings.Split(n.Content, "\n") {
+				fmt.Println("  " + line)
+			}
+		}
+	}
+
+	if len(d.History) > 0 {
+		fmt.Println("History:")
+		for _, h := range d.History {
+			fmt.Println("  " + h.At + " " + h.Action)
+		}
+	}
+}
+
+func printItemJSON(d itemDetail) {
+	out, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		fatalError(err)
+	}
+	fmt.Println(string(out))
+}
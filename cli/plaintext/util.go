@@ -1,8 +1,25 @@
 package plaintext
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tudo/core/capture"
+	"tudo/core/contexts"
+	"tudo/core/log"
+	"tudo/core/projects"
+	"tudo/core/reading"
+	"tudo/core/someday"
+	"tudo/core/tags"
+	"tudo/core/tasks"
+	"tudo/core/templates"
+	"tudo/core/waiting"
 )
 
 func todo() {
@@ -29,3 +46,431 @@ func nonFatalError(err error, args ...string) {
 	fmt.Println(e)
 	os.Exit(0)
 }
+
+// parseFlags splits args into positional arguments and flags given as
+// `--name value` or `--name=value`. Flags listed in boolFlags never consume
+// the following argument. Repeated flags are joined with a comma; every
+// value is also kept separately, in order, for flags whose values may
+// themselves contain commas.
+func parseFlags(args []string, boolFlags []string) ([]string, map[string]string, map[string][]string) {
+	isBool := make(map[string]bool)
+	for _, f := range boolFlags {
+		isBool[f] = true
+	}
+
+	var positional []string
+	flags := make(map[string]string)
+	values := make(map[string][]string)
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") || args[i] == "--" {
+			positional = append(positional, args[i])
+			continue
+		}
+
+		name := strings.TrimPrefix(args[i], "--")
+		value := ""
+		if k, v, found := strings.Cut(name, "="); found {
+			name, value = k, v
+		} else if !isBool[name] && i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			value = args[i+1]
+			i++
+		}
+
+		values[name] = append(values[name], value)
+		if prev, ok := flags[name]; ok && prev != "" {
+			value = prev + "," + value
+		}
+		flags[name] = value
+	}
+	return positional, flags, values
+}
+
+// contextSubtree resolves a context name, optionally written as `@name` or
+// as a `parent/child` path, to the set of names of that context and all of
+// its descendants.
+func contextSubtree(db *sql.DB, name string) (map[string]bool, error) {
+	name = strings.TrimPrefix(name, "@")
+
+	exists, id, err := contexts.FindPath(db, name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("No context `" + name + "` exists")
+	}
+
+	subtree, err := contexts.Subtree(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, c := range subtree {
+		names[c.Content] = true
+	}
+	return names, nil
+}
+
+// finishTask marks a task as done, logs it for undo and lists the tasks it
+// no longer blocks.
+func finishTask(db *sql.DB, id uint32) {
+	if err := tasks.Done(db, id); err != nil {
+		fatalError(err)
+	}
+
+	task, err := tasks.Get(db, id)
+	if err != nil {
+		fatalError(err)
+	}
+
+	fmt.Print(fmt.Sprint("Finished task `", id, "`\n`", task.Content, "`\n"))
+
+	if err := log.New(db, "tasks", id); err != nil {
+		fatalError(err)
+	}
+
+	unblocked, err := tasks.GetUnblockedBy(db, id)
+	if err != nil {
+		fatalError(err)
+	}
+	for _, t := range unblocked {
+		fmt.Print(fmt.Sprint("Unblocked task `", t.ID, "`\n`", t.Content, "`\n"))
+	}
+}
+
+// actionableTasks returns the unblocked standalone next actions together
+// with the next action of every active project.
+func actionableTasks(db *sql.DB) []tasks.TudoTask {
+	nextActions, err := tasks.GetActiveNextActions(db)
+	if err != nil {
+		fatalError(err)
+	}
+	projectActions, err := tasks.GetProjectNextActions(db)
+	if err != nil {
+		fatalError(err)
+	}
+	return append(withoutBlocked(db, nextActions), projectActions...)
+}
+
+// withoutBlocked drops the tasks that still wait on a prerequisite.
+func withoutBlocked(db *sql.DB, taskList []tasks.TudoTask) []tasks.TudoTask {
+	blocked, err := tasks.BlockedIDs(db)
+	if err != nil {
+		fatalError(err)
+	}
+
+	var filtered []tasks.TudoTask
+	for _, t := range taskList {
+		if !blocked[t.ID] {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// filterEffort keeps the tasks fitting the --time and --energy flags. Tasks
+// without an estimate or energy level never match a filter on that field.
+func filterEffort(taskList []tasks.TudoTask, flags map[string]string) []tasks.TudoTask {
+	timeStr, filterTime := flags["time"]
+	energyStr, filterEnergy := flags["energy"]
+	if !filterTime && !filterEnergy {
+		return taskList
+	}
+
+	var available uint32
+	if filterTime {
+		var err error
+		available, err = tasks.ParseEstimate(timeStr)
+		if err != nil {
+			nonFatalError(err)
+		}
+	}
+	maxEnergy := -1
+	if filterEnergy {
+		energy, err := tasks.ParseEnergy(energyStr)
+		if err != nil {
+			nonFatalError(err)
+		}
+		maxEnergy = tasks.EnergyRank(energy)
+	}
+
+	var filtered []tasks.TudoTask
+	for _, t := range taskList {
+		if filterTime && (t.Estimate == nil || *t.Estimate > available) {
+			continue
+		}
+		if filterEnergy && (t.Energy == nil || tasks.EnergyRank(*t.Energy) > maxEnergy) {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
+}
+
+// sortKey returns the value of the --sort flag, or def when it was not
+// given, failing when the key is not one of allowed.
+func sortKey(flags map[string]string, def string, allowed ...string) string {
+	key, ok := flags["sort"]
+	if !ok {
+		return def
+	}
+	for _, a := range allowed {
+		if key == a {
+			return key
+		}
+	}
+	nonFatalError(errors.New("Invalid sort `" + key + "`, expected one of " + strings.Join(allowed, ", ")))
+	return ""
+}
+
+var taskSortKeys = []string{"priority", "due", "created", "project"}
+
+// sortTasks orders tasks by the given key. Tasks missing the field sort
+// last and ties keep their original order.
+func sortTasks(db *sql.DB, taskList []tasks.TudoTask, key string) {
+	compareOptional := func(a, b *string) int {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return 1
+		case b == nil:
+			return -1
+		}
+		return strings.Compare(*a, *b)
+	}
+
+	projectNames := make(map[uint32]*string)
+	projectName := func(t tasks.TudoTask) *string {
+		if t.ProjectID == nil {
+			return nil
+		}
+		if name, ok := projectNames[*t.ProjectID]; ok {
+			return name
+		}
+		p, err := projects.Get(db, *t.ProjectID)
+		if err != nil {
+			fatalError(err)
+		}
+		projectNames[*t.ProjectID] = &p.Content
+		return &p.Content
+	}
+
+	sort.SliceStable(taskList, func(i, j int) bool {
+		a, b := taskList[i], taskList[j]
+		switch key {
+		case "priority":
+			if c := compareOptional(a.Priority, b.Priority); c != 0 {
+				return c < 0
+			}
+			return compareOptional(a.Due, b.Due) < 0
+		case "due":
+			return compareOptional(a.Due, b.Due) < 0
+		case "created":
+			return a.CreatedAt < b.CreatedAt
+		case "project":
+			return compareOptional(projectName(a), projectName(b)) < 0
+		}
+		return false
+	})
+}
+
+// itemTable maps the item type used on the command line to its table.
+func itemTable(kind string) string {
+	switch kind {
+	case "task":
+		return "tasks"
+	case "project":
+		return "projects"
+	case "waiting":
+		return "waiting"
+	case "someday":
+		return "someday"
+	case "in":
+		return "capture"
+	case "read":
+		return "reading"
+	}
+	nonFatalError(invalidCommand, kind)
+	return ""
+}
+
+// itemExists reports whether an item of the given command line type exists.
+func itemExists(db *sql.DB, kind string, id uint32) bool {
+	var exists bool
+	var err error
+	switch kind {
+	case "task":
+		exists, err = tasks.IDExists(db, id)
+	case "project":
+		exists, err = projects.IDExists(db, id)
+	case "waiting":
+		exists, err = waiting.IDExists(db, id)
+	case "someday":
+		exists, err = someday.IDExists(db, id)
+	case "in":
+		exists, err = capture.IDExists(db, id)
+	case "read":
+		exists, err = reading.IDExists(db, id)
+	default:
+		nonFatalError(invalidCommand, kind)
+	}
+	if err != nil {
+		fatalError(err)
+	}
+	return exists
+}
+
+// applyTags attaches the tags given with --tag to a row and detaches the
+// ones given with --untag.
+func applyTags(db *sql.DB, flags map[string]string, table string, rowID uint32) {
+	for _, tag := range tags.Parse(flags["tag"]) {
+		if err := tags.Add(db, table, rowID, tag); err != nil {
+			fatalError(err)
+		}
+	}
+	for _, tag := range tags.Parse(flags["untag"]) {
+		if err := tags.Remove(db, table, rowID, tag); err != nil {
+			fatalError(err)
+		}
+	}
+}
+
+// tagMatcher returns whether a row of the table carries every tag given
+// with --tag and none of the ones given with --not-tag.
+func tagMatcher(db *sql.DB, flags map[string]string, table string) func(uint32) bool {
+	required := tags.Parse(flags["tag"])
+	excluded := tags.Parse(flags["not-tag"])
+	if len(required) == 0 && len(excluded) == 0 {
+		return func(uint32) bool { return true }
+	}
+
+	tagMap, err := tags.GetAll(db, table)
+	if err != nil {
+		fatalError(err)
+	}
+
+	return func(rowID uint32) bool {
+		has := make(map[string]bool)
+		for _, tag := range tagMap[rowID] {
+			has[tag] = true
+		}
+		for _, tag := range required {
+			if !has[tag] {
+				return false
+			}
+		}
+		for _, tag := range excluded {
+			if has[tag] {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func filterTasksByTag(db *sql.DB, flags map[string]string, taskList []tasks.TudoTask) []tasks.TudoTask {
+	match := tagMatcher(db, flags, "tasks")
+	var filtered []tasks.TudoTask
+	for _, t := range taskList {
+		if match(t.ID) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// lookupProject resolves the words of a project name, or a project id, to an
+// active project. A number that is not the id of any project is looked up
+// as a name.
+func lookupProject(db *sql.DB, words []string) (uint32, string) {
+	name := strings.Join(words, " ")
+	if p, ok := projectByID(db, name); ok {
+		if p.Done || p.ArchivedAt != nil {
+			nonFatalError(errors.New("No active project `" + name + "` exists"))
+		}
+		return p.ID, p.Content
+	}
+
+	exists, id, err := projects.ContentExists(db, name)
+	if err != nil {
+		fatalError(err)
+	}
+	if !exists {
+		nonFatalError(errors.New("No active project `" + name + "` exists"))
+	}
+	return id, name
+}
+
+// lookupAnyProject is lookupProject for projects in any state, finished
+// and archived ones included.
+func lookupAnyProject(db *sql.DB, words []string) (uint32, string) {
+	name := strings.Join(words, " ")
+	if p, ok := projectByID(db, name); ok {
+		return p.ID, p.Content
+	}
+
+	exists, id, err := projects.Find(db, name)
+	if err != nil {
+		fatalError(err)
+	}
+	if !exists {
+		nonFatalError(errors.New("No project `" + name + "` exists"))
+	}
+	return id, name
+}
+
+// projectByID returns the project whose id is given as name, if any.
+func projectByID(db *sql.DB, name string) (projects.TudoProject, bool) {
+	id, err := strconv.Atoi(name)
+	if err != nil {
+		return projects.TudoProject{}, false
+	}
+	p, err := projects.Get(db, uint32(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return projects.TudoProject{}, false
+	} else if err != nil {
+		fatalError(err)
+	}
+	return p, true
+}
+
+// idleDays returns the value of the --days flag used to flag projects
+// without recent activity.
+func idleDays(flags map[string]string) int {
+	daysStr, ok := flags["days"]
+	if !ok {
+		return 14
+	}
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days < 0 {
+		nonFatalError(errors.New("Invalid number of days `" + daysStr + "`"))
+	}
+	return days
+}
+
+// templateDir returns the directory project templates are read from, next
+// to the database file.
+func templateDir(dbFile string) string {
+	return filepath.Join(filepath.Dir(dbFile), "templates")
+}
+
+// templateFlags returns the flags with the project fields of the template
+// filled in where they were not given, so creating a project from a
+// template asks for nothing.
+func templateFlags(template templates.TudoTemplate, flags map[string]string) map[string]string {
+	merged := map[string]string{"order": "parallel", "outcome": "", "purpose": "", "area": ""}
+	if template.Sequential {
+		merged["order"] = "sequential"
+	}
+	if template.Outcome != nil {
+		merged["outcome"] = *template.Outcome
+	}
+	if template.Purpose != nil {
+		merged["purpose"] = *template.Purpose
+	}
+	for k, v := range flags {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,108 @@
+package areas
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// Horizons are the GTD horizons of focus above projects, from areas of
+// responsibility (2) up to purpose and principles (5).
+var Horizons = []string{"area", "goal", "vision", "purpose"}
+
+// HorizonArea is the horizon of areas of responsibility, the only one
+// projects are expected to be linked to.
+const HorizonArea = 2
+
+type TudoArea struct {
+	ID        uint32
+	Content   string
+	Horizon   int
+	ParentID  *uint32
+	CreatedAt string
+}
+
+// ParseHorizon maps a horizon name such as `goal` to its number.
+func ParseHorizon(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, h := range Horizons {
+		if h == s {
+			return i + HorizonArea, nil
+		}
+	}
+	return 0, errors.New("Invalid horizon `" + s + "`, expected " + strings.Join(Horizons, ", "))
+}
+
+// HorizonName returns the name of the given horizon number.
+func HorizonName(horizon int) string {
+	if horizon < HorizonArea || horizon >= HorizonArea+len(Horizons) {
+		return "unknown"
+	}
+	return Horizons[horizon-HorizonArea]
+}
+
+func New(db *sql.DB, area TudoArea) (uint32, error) {
+	res, err := db.Exec("INSERT INTO areas (id, content, horizon, parent_id, created_at) VALUES (NULL, ?, ?, ?, date())", area.Content, area.Horizon, area.ParentID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
+}
+
+func ContentExists(db *sql.DB, content string) (bool, uint32, error) {
+	row := db.QueryRow("SELECT id FROM areas WHERE content = ?", content)
+	var id uint32
+	err := row.Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, 0, nil
+	} else if err != nil {
+		return false, 0, err
+	}
+
+	return true, id, nil
+}
+
+func Get(db *sql.DB, id uint32) (TudoArea, error) {
+	row := db.QueryRow("SELECT id, content, horizon, parent_id, created_at FROM areas WHERE id = ?", id)
+	var a TudoArea
+	if err := row.Scan(&a.ID, &a.Content, &a.Horizon, &a.ParentID, &a.CreatedAt); err != nil {
+		return TudoArea{}, err
+	}
+	return a, nil
+}
+
+// GetAll returns every area, highest horizon first.
+func GetAll(db *sql.DB) ([]TudoArea, error) {
+	return query(db, "SELECT id, content, horizon, parent_id, created_at FROM areas ORDER BY horizon DESC, content")
+}
+
+// WithoutActiveProjects returns the areas of responsibility no active
+// project is linked to.
+func WithoutActiveProjects(db *sql.DB) ([]TudoArea, error) {
+	return query(db, `
+SELECT id, content, horizon, parent_id, created_at FROM areas a
+WHERE a.horizon = ? AND NOT EXISTS (SELECT 1 FROM projects p WHERE p.area_id = a.id AND p.done = 0)
+ORDER BY content`, HorizonArea)
+}
+
+func query(db *sql.DB, query string, args ...any) ([]TudoArea, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return []TudoArea{}, err
+	}
+	defer rows.Close()
+
+	var areas []TudoArea
+	for rows.Next() {
+		var a TudoArea
+		if err := rows.Scan(&a.ID, &a.Content, &a.Horizon, &a.ParentID, &a.CreatedAt); err != nil {
+			return []TudoArea{}, err
+		}
+		areas = append(areas, a)
+	}
+	return areas, nil
+}
@@ -12,11 +12,16 @@ type TudoCapture struct {
 	CreatedAt string
 }
 
-func New(db *sql.DB, captureTxt string) error {
-	if _, err := db.Exec("INSERT INTO capture (id, content, done, created_at) VALUES (NULL, ?, 0, date());", captureTxt); err != nil {
-		return err
+func New(db *sql.DB, captureTxt string) (uint32, error) {
+	res, err := db.Exec("INSERT INTO capture (id, content, done, created_at) VALUES (NULL, ?, 0, date());", captureTxt)
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
 }
 
 func IDExists(db *sql.DB, id uint32) (bool, error) {
@@ -30,6 +35,15 @@ func IDExists(db *sql.DB, id uint32) (bool, error) {
 	return true, nil
 }
 
+func Get(db *sql.DB, id uint32) (TudoCapture, error) {
+	row := db.QueryRow("SELECT id, content, done, created_at FROM capture WHERE id = ?", id)
+	var c TudoCapture
+	if err := row.Scan(&c.ID, &c.Content, &c.Done, &c.CreatedAt); err != nil {
+		return TudoCapture{}, err
+	}
+	return c, nil
+}
+
 func GetActive(db *sql.DB) ([]TudoCapture, error) {
 	rows, err := db.Query("SELECT id, content, done, created_at FROM capture WHERE done == 0")
 	if err != nil {
@@ -0,0 +1,74 @@
+package checklist
+
+import (
+	"database/sql"
+)
+
+// TudoChecklistItem is a small step of a task that is ticked off without
+// becoming a task of its own.
+type TudoChecklistItem struct {
+	ID        uint32
+	TaskID    uint32
+	Content   string
+	Done      bool
+	CreatedAt string
+}
+
+func New(db *sql.DB, taskID uint32, content string) error {
+	if _, err := db.Exec("INSERT INTO checklist (id, task_id, content, done, created_at) VALUES (NULL, ?, ?, 0, date())", taskID, content); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Get(db *sql.DB, id uint32) (TudoChecklistItem, error) {
+	row := db.QueryRow("SELECT id, task_id, content, done, created_at FROM checklist WHERE id = ?", id)
+	var c TudoChecklistItem
+	if err := row.Scan(&c.ID, &c.TaskID, &c.Content, &c.Done, &c.CreatedAt); err != nil {
+		return TudoChecklistItem{}, err
+	}
+	return c, nil
+}
+
+func GetForTask(db *sql.DB, taskID uint32) ([]TudoChecklistItem, error) {
+	rows, err := db.Query("SELECT id, task_id, content, done, created_at FROM checklist WHERE task_id = ? ORDER BY id", taskID)
+	if err != nil {
+		return []TudoChecklistItem{}, err
+	}
+	defer rows.Close()
+
+	var items []TudoChecklistItem
+	for rows.Next() {
+		var c TudoChecklistItem
+		if err := rows.Scan(&c.ID, &c.TaskID, &c.Content, &c.Done, &c.CreatedAt); err != nil {
+			return []TudoChecklistItem{}, err
+		}
+		items = append(items, c)
+	}
+	return items, nil
+}
+
+func Check(db *sql.DB, id uint32) error {
+	if _, err := db.Exec("UPDATE checklist SET done = 1 WHERE id = ?", id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Uncheck(db *sql.DB, id uint32) error {
+	if _, err := db.Exec("UPDATE checklist SET done = 0 WHERE id = ?", id); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Progress returns the number of checked items and the total number of
+// items of a task's checklist.
+func Progress(db *sql.DB, taskID uint32) (int, int, error) {
+	row := db.QueryRow("SELECT COALESCE(SUM(done), 0), COUNT(*) FROM checklist WHERE task_id = ?", taskID)
+	var done, total int
+	if err := row.Scan(&done, &total); err != nil {
+		return 0, 0, err
+	}
+	return done, total, nil
+}
@@ -3,15 +3,18 @@ package contexts
 import (
 	"database/sql"
 	"errors"
+	"strings"
 )
 
 type TudoContext struct {
-	ID      uint32
-	Content string
+	ID       uint32
+	Content  string
+	ParentID *uint32
+	Depth    int
 }
 
-func New(db *sql.DB, content string) error {
-	if _, err := db.Exec("INSERT INTO contexts (id, content) VALUES (NULL, ?)", content); err != nil {
+func New(db *sql.DB, content string, parentID *uint32) error {
+	if _, err := db.Exec("INSERT INTO contexts (id, content, parent_id) VALUES (NULL, ?, ?)", content, parentID); err != nil {
 		return err
 	}
 	return nil
@@ -32,9 +35,9 @@ func ContentExists(db *sql.DB, content string) (bool, uint32, error) {
 }
 
 func Get(db *sql.DB, id uint32) (TudoContext, error) {
-	row := db.QueryRow("SELECT id, content FROM contexts WHERE id = ?", id)
+	row := db.QueryRow("SELECT id, content, parent_id FROM contexts WHERE id = ?", id)
 	var c TudoContext
-	err := row.Scan(&c.ID, &c.Content)
+	err := row.Scan(&c.ID, &c.Content, &c.ParentID)
 	if err != nil {
 		return TudoContext{}, err
 	}
@@ -42,8 +45,66 @@ func Get(db *sql.DB, id uint32) (TudoContext, error) {
 	return c, nil
 }
 
+// GetAll returns every context ordered as a tree, each child directly after
+// its parent, with Depth set to the number of ancestors. Paths are joined
+// with char(1), which sorts below every printable character, so a sibling
+// can never sort between a parent and its children.
 func GetAll(db *sql.DB) ([]TudoContext, error) {
-	rows, err := db.Query("SELECT id, content FROM contexts")
+	rows, err := db.Query(`
+WITH RECURSIVE tree(id, content, parent_id, depth, path) AS (
+  SELECT id, content, parent_id, 0, content FROM contexts WHERE parent_id IS NULL
+  UNION ALL
+  SELECT c.id, c.content, c.parent_id, t.depth + 1, t.path || char(1) || c.content
+  FROM contexts c JOIN tree t ON c.parent_id = t.id
+)
+SELECT id, content, parent_id, depth FROM tree ORDER BY path`)
+	if err != nil {
+		return []TudoContext{}, err
+	}
+	defer rows.Close()
+
+	var contexts []TudoContext
+	for rows.Next() {
+		var c TudoContext
+		if err := rows.Scan(&c.ID, &c.Content, &c.ParentID, &c.Depth); err != nil {
+			return []TudoContext{}, err
+		}
+		contexts = append(contexts, c)
+	}
+	return contexts, nil
+}
+
+// FindPath resolves a `parent/child` path to the id of its last context. The
+// first name may be at any depth, each following one must be a child of the
+// context before it.
+func FindPath(db *sql.DB, path string) (bool, uint32, error) {
+	names := strings.Split(path, "/")
+	exists, id, err := ContentExists(db, names[0])
+	if err != nil || !exists {
+		return false, 0, err
+	}
+	for _, name := range names[1:] {
+		row := db.QueryRow("SELECT id FROM contexts WHERE content = ? AND parent_id = ?", name, id)
+		if err := row.Scan(&id); errors.Is(err, sql.ErrNoRows) {
+			return false, 0, nil
+		} else if err != nil {
+			return false, 0, err
+		}
+	}
+	return true, id, nil
+}
+
+// Subtree returns the context with the given id followed by all of its
+// descendants.
+func Subtree(db *sql.DB, id uint32) ([]TudoContext, error) {
+	rows, err := db.Query(`
+WITH RECURSIVE tree(id, content, parent_id, depth) AS (
+  SELECT id, content, parent_id, 0 FROM contexts WHERE id = ?
+  UNION ALL
+  SELECT c.id, c.content, c.parent_id, t.depth + 1
+  FROM contexts c JOIN tree t ON c.parent_id = t.id
+)
+SELECT id, content, parent_id, depth FROM tree`, id)
 	if err != nil {
 		return []TudoContext{}, err
 	}
@@ -52,7 +113,7 @@ func GetAll(db *sql.DB) ([]TudoContext, error) {
 	var contexts []TudoContext
 	for rows.Next() {
 		var c TudoContext
-		if err := rows.Scan(&c.ID, &c.Content); err != nil {
+		if err := rows.Scan(&c.ID, &c.Content, &c.ParentID, &c.Depth); err != nil {
 			return []TudoContext{}, err
 		}
 		contexts = append(contexts, c)
@@ -0,0 +1,216 @@
+package contexts
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TudoSchedule is a window of time in which a context is available. Weekdays
+// is a comma separated list of three letter day names and the times are
+// given as HH:MM. A window whose end is not after its start runs past
+// midnight into the following day.
+type TudoSchedule struct {
+	ID        uint32
+	ContextID uint32
+	Weekdays  string
+	Start     string
+	End       string
+}
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+var invalidWeekdays error = errors.New("Invalid weekdays, expected e.g. `mon-fri` or `sat,sun`")
+
+var invalidHours error = errors.New("Invalid hours, expected e.g. `09:00-17:00`")
+
+func NewSchedule(db *sql.DB, contextID uint32, days, start, end string) error {
+	if _, err := db.Exec("INSERT INTO context_schedules (id, context_id, weekdays, start_time, end_time) VALUES (NULL, ?, ?, ?, ?)", contextID, days, start, end); err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetSchedules(db *sql.DB, contextID uint32) ([]TudoSchedule, error) {
+	rows, err := db.Query("SELECT id, context_id, weekdays, start_time, end_time FROM context_schedules WHERE context_id = ?", contextID)
+	if err != nil {
+		return []TudoSchedule{}, err
+	}
+	defer rows.Close()
+
+	var schedules []TudoSchedule
+	for rows.Next() {
+		var s TudoSchedule
+		if err := rows.Scan(&s.ID, &s.ContextID, &s.Weekdays, &s.Start, &s.End); err != nil {
+			return []TudoSchedule{}, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+func ClearSchedules(db *sql.DB, contextID uint32) error {
+	if _, err := db.Exec("DELETE FROM context_schedules WHERE context_id = ?", contextID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseWeekdays normalises a list of days such as `mon-fri`, `sat,sun` or
+// `mon,wed-fri` into a comma separated list of day names. An empty string
+// means every day.
+func ParseWeekdays(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return strings.Join(weekdays, ","), nil
+	}
+
+	selected := make([]bool, len(weekdays))
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start := dayIndex(from)
+		if start < 0 {
+			return "", invalidWeekdays
+		}
+		end := start
+		if isRange {
+			end = dayIndex(to)
+			if end < 0 {
+				return "", invalidWeekdays
+			}
+		}
+		for i := start; ; i = (i + 1) % len(weekdays) {
+			selected[i] = true
+			if i == end {
+				break
+			}
+		}
+	}
+
+	var days []string
+	for i, day := range weekdays {
+		if selected[i] {
+			days = append(days, day)
+		}
+	}
+	return strings.Join(days, ","), nil
+}
+
+// ParseHours splits a window such as `09:00-17:00` into its start and end,
+// normalised to `15:04` so that `9:00` is stored as `09:00`. An empty string
+// means the whole day.
+func ParseHours(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "00:00", "24:00", nil
+	}
+
+	start, end, found := strings.Cut(s, "-")
+	if !found {
+		return "", "", invalidHours
+	}
+	startTime, err := time.Parse("15:04", strings.TrimSpace(start))
+	if err != nil {
+		return "", "", invalidHours
+	}
+	start = startTime.Format("15:04")
+	if end = strings.TrimSpace(end); end != "24:00" {
+		endTime, err := time.Parse("15:04", end)
+		if err != nil {
+			return "", "", invalidHours
+		}
+		end = endTime.Format("15:04")
+	}
+	if start == end {
+		return "", "", invalidHours
+	}
+	return start, end, nil
+}
+
+// clockMinutes returns the minutes since midnight of a `15:04` clock time,
+// also accepting single-digit hours stored before they were normalised.
+func clockMinutes(clock string) int {
+	h, m, _ := strings.Cut(clock, ":")
+	hours, _ := strconv.Atoi(h)
+	minutes, _ := strconv.Atoi(m)
+	return hours*60 + minutes
+}
+
+// dayIndex returns the index in weekdays of a three letter day name or a
+// full day name, or -1 for anything else.
+func dayIndex(day string) int {
+	day = strings.ToLower(strings.TrimSpace(day))
+	for i, d := range weekdays {
+		if day == d || day == strings.ToLower(time.Weekday(i).String()) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s TudoSchedule) Contains(now time.Time) bool {
+	clock := now.Hour()*60 + now.Minute()
+	start, end := clockMinutes(s.Start), clockMinutes(s.End)
+	today := weekdays[now.Weekday()]
+	yesterday := weekdays[(now.Weekday()+6)%7]
+	days := strings.Split(s.Weekdays, ",")
+
+	onDay := func(day string) bool {
+		for _, d := range days {
+			if d == day {
+				return true
+			}
+		}
+		return false
+	}
+
+	if start < end {
+		return onDay(today) && start <= clock && clock < end
+	}
+	return (onDay(today) && start <= clock) || (onDay(yesterday) && clock < end)
+}
+
+// Available reports for every context whether it is available at the given
+// time. A context without schedules is always available, but never while
+// its parent is unavailable.
+func Available(db *sql.DB, now time.Time) (map[string]bool, error) {
+	contextList, err := GetAll(db)
+	if err != nil {
+		return map[string]bool{}, err
+	}
+
+	rows, err := db.Query("SELECT id, context_id, weekdays, start_time, end_time FROM context_schedules")
+	if err != nil {
+		return map[string]bool{}, err
+	}
+	defer rows.Close()
+
+	schedules := make(map[uint32][]TudoSchedule)
+	for rows.Next() {
+		var s TudoSchedule
+		if err := rows.Scan(&s.ID, &s.ContextID, &s.Weekdays, &s.Start, &s.End); err != nil {
+			return map[string]bool{}, err
+		}
+		schedules[s.ContextID] = append(schedules[s.ContextID], s)
+	}
+
+	availableByID := make(map[uint32]bool)
+	available := make(map[string]bool)
+	for _, c := range contextList {
+		open := len(schedules[c.ID]) == 0
+		for _, s := range schedules[c.ID] {
+			if s.Contains(now) {
+				open = true
+				break
+			}
+		}
+		if c.ParentID != nil && !availableByID[*c.ParentID] {
+			open = false
+		}
+		availableByID[c.ID] = open
+		available[c.Content] = open
+	}
+	return available, nil
+}
@@ -9,20 +9,34 @@ type TudoLog struct {
 	TableName string
 	RowID     uint32
 	CreatedAt string
+	Action    string
 }
 
-func New(db *sql.DB, table string, rowID uint32) error {
-	if _, err := db.Exec("INSERT INTO action_log (id, table_name, row_id, created_at) VALUES (NULL, ?, ?, datetime())", "capture", rowID); err != nil {
+// Execer is a database or a transaction, so that changes can be logged
+// together with the change itself.
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// New records that a row was marked as done, which `undo` can revert.
+func New(db Execer, table string, rowID uint32) error {
+	return NewAction(db, table, rowID, "done")
+}
+
+// NewAction records any other change to a row for its history.
+func NewAction(db Execer, table string, rowID uint32, action string) error {
+	if _, err := db.Exec("INSERT INTO action_log (id, table_name, row_id, created_at, action) VALUES (NULL, ?, ?, datetime(), ?)", table, rowID, action); err != nil {
 		return err
 	}
 	return nil
 }
 
+// Get returns the last done action, the one `undo` reverts.
 func Get(db *sql.DB) (TudoLog, bool, error) {
-	row := db.QueryRow("SELECT id, table_name, row_id, created_at FROM action_log ORDER BY id DESC LIMIT 1")
+	row := db.QueryRow("SELECT id, table_name, row_id, created_at, action FROM action_log WHERE action = 'done' ORDER BY id DESC LIMIT 1")
 
 	var a TudoLog
-	err := row.Scan(&a.ID, &a.TableName, &a.RowID, &a.CreatedAt)
+	err := row.Scan(&a.ID, &a.TableName, &a.RowID, &a.CreatedAt, &a.Action)
 	if err != nil {
 		return TudoLog{}, false, err
 	}
@@ -33,8 +47,19 @@ func Get(db *sql.DB) (TudoLog, bool, error) {
 	return a, true, nil
 }
 
+// Undo reopens the row of a done action and removes the action, clearing
+// what finishing the row set on tables that record it.
 func Undo(db *sql.DB, table string, id, rowID uint32) error {
-	if _, err := db.Exec("UPDATE "+table+" SET done = 0 WHERE id = ?", rowID); err != nil {
+	reopen := "done = 0"
+	switch table {
+	case "tasks":
+		reopen = "done = 0, cancelled = 0, finished_at = NULL"
+	case "projects", "waiting":
+		reopen = "done = 0, finished_at = NULL"
+	case "reading":
+		reopen = "done = 0, read_at = NULL"
+	}
+	if _, err := db.Exec("UPDATE "+table+" SET "+reopen+" WHERE id = ?", rowID); err != nil {
 		return err
 	}
 	if _, err := db.Exec("DELETE FROM action_log WHERE id = ?", id); err != nil {
@@ -42,3 +67,21 @@ func Undo(db *sql.DB, table string, id, rowID uint32) error {
 	}
 	return nil
 }
+
+func History(db *sql.DB, table string, rowID uint32) ([]TudoLog, error) {
+	rows, err := db.Query("SELECT id, table_name, row_id, created_at, action FROM action_log WHERE table_name = ? AND row_id = ? ORDER BY id", table, rowID)
+	if err != nil {
+		return []TudoLog{}, err
+	}
+	defer rows.Close()
+
+	var history []TudoLog
+	for rows.Next() {
+		var a TudoLog
+		if err := rows.Scan(&a.ID, &a.TableName, &a.RowID, &a.CreatedAt, &a.Action); err != nil {
+			return []TudoLog{}, err
+		}
+		history = append(history, a)
+	}
+	return history, nil
+}
@@ -0,0 +1,155 @@
+package move
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+var ErrSameList error = errors.New("Item is already on that list")
+
+var ErrNotActive error = errors.New("Only unfinished items can be moved")
+
+// TudoDestination is where an item is moved to: the tasks, someday or
+// waiting table, for tasks the project, nil for a next action, and for
+// waiting the person it is delegated to, kept from the item when nil, and
+// the dates it is expected by and to be followed up on.
+type TudoDestination struct {
+	Table      string
+	ProjectID  *uint32
+	PersonID   *uint32
+	ExpectedBy *string
+	FollowUp   *string
+}
+
+// Item moves an unfinished task, someday or waiting item to the given
+// destination and returns its id there. Within the tasks table only the
+// project changes, and a task that becomes a next action loses its due date
+// so that it does not stay on the calendar. Moving to another table converts the row, keeping its
+// creation date and carrying its notes, tags, history, linked person and
+// context over, as well as the project of a task that becomes a waiting
+// item; task fields the target has no place for are dropped, except the
+// checklist which is kept as a note.
+func Item(db *sql.DB, table string, id uint32, to TudoDestination) (uint32, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Someday items are not linked to anyone, any project or context.
+	links := "NULL, NULL, NULL"
+	if table != "someday" {
+		links = "person_id, project_id, context"
+	}
+	var content, createdAt string
+	var person, project *uint32
+	var context *string
+	if err := tx.QueryRow("SELECT content, created_at, "+links+" FROM "+table+" WHERE id = ? AND done = 0", id).Scan(&content, &createdAt, &person, &project, &context); errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotActive
+	} else if err != nil {
+		return 0, err
+	}
+
+	sameProject := project == nil && to.ProjectID == nil || project != nil && to.ProjectID != nil && *project == *to.ProjectID
+	if table == to.Table && (table != "tasks" || sameProject) {
+		return 0, ErrSameList
+	}
+
+	if table == "tasks" && to.Table == "tasks" {
+		if _, err := tx.Exec(`
+UPDATE tasks SET project_id = ?1,
+  position = CASE WHEN ?1 IS NULL THEN NULL ELSE (SELECT COALESCE(MAX(position), 0) + 1 FROM tasks WHERE project_id = ?1) END,
+  due = CASE WHEN ?1 IS NULL THEN NULL ELSE due END
+WHERE id = ?2`, to.ProjectID, id); err != nil {
+			return 0, err
+		}
+		return id, tx.Commit()
+	}
+
+	var res sql.Result
+	switch to.Table {
+	case "tasks":
+		res, err = tx.Exec(`
+INSERT INTO tasks (id, content, project_id, done, created_at, person_id, context, position)
+VALUES (NULL, ?1, ?2, 0, ?3, ?4, ?5, CASE WHEN ?2 IS NULL THEN NULL ELSE (SELECT COALESCE(MAX(position), 0) + 1 FROM tasks WHERE project_id = ?2) END)`,
+			content, to.ProjectID, createdAt, person, context)
+	case "waiting":
+		if to.PersonID != nil {
+			person = to.PersonID
+		}
+		res, err = tx.Exec(`
+INSERT INTO waiting (id, content, done, created_at, person_id, project_id, context, delegated_at, expected_by, follow_up)
+VALUES (NULL, ?, 0, ?, ?, ?, ?, date(), ?, ?)`, content, createdAt, person, project, context, to.ExpectedBy, to.FollowUp)
+	case "someday":
+		res, err = tx.Exec("INSERT INTO someday (id, content, done, created_at) VALUES (NULL, ?, 0, ?)", content, createdAt)
+	default:
+		return 0, errors.New("Cannot move items to `" + to.Table + "`")
+	}
+	if err != nil {
+		return 0, err
+	}
+	newID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if table == "tasks" {
+		if err := dropTaskRows(tx, id); err != nil {
+			return 0, err
+		}
+	}
+	for _, t := range []string{"notes", "item_tags", "action_log"} {
+		if _, err := tx.Exec("UPDATE "+t+" SET table_name = ?, row_id = ? WHERE table_name = ? AND row_id = ?", to.Table, newID, table, id); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM "+table+" WHERE id = ?", id); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return uint32(newID), nil
+}
+
+// dropTaskRows removes the rows that only make sense for a task: its
+// dependencies and checklist, which is turned into a note first.
+func dropTaskRows(tx *sql.Tx, id uint32) error {
+	rows, err := tx.Query("SELECT content, done FROM checklist WHERE task_id = ? ORDER BY id", id)
+	if err != nil {
+		return err
+	}
+	var items []string
+	for rows.Next() {
+		var content string
+		var done bool
+		if err := rows.Scan(&content, &done); err != nil {
+			rows.Close()
+			return err
+		}
+		box := "[ ] "
+		if done {
+			box = "[x] "
+		}
+		items = append(items, box+content)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(items) > 0 {
+		if _, err := tx.Exec("INSERT INTO notes (id, table_name, row_id, content, created_at) VALUES (NULL, 'tasks', ?, ?, datetime())", id, "Checklist:\n"+strings.Join(items, "\n")); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM checklist WHERE task_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM task_dependencies WHERE task_id = ? OR blocked_by = ?", id, id); err != nil {
+		return err
+	}
+	return nil
+}
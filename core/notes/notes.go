@@ -0,0 +1,72 @@
+package notes
+
+import (
+	"database/sql"
+)
+
+// TudoNote is a timestamped entry attached to a row of an item table, in the
+// same way as tags.
+type TudoNote struct {
+	ID        uint32
+	TableName string
+	RowID     uint32
+	Content   string
+	CreatedAt string
+}
+
+func New(db *sql.DB, table string, rowID uint32, content string) error {
+	if _, err := db.Exec("INSERT INTO notes (id, table_name, row_id, content, created_at) VALUES (NULL, ?, ?, ?, datetime())", table, rowID, content); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Get(db *sql.DB, id uint32) (TudoNote, error) {
+	row := db.QueryRow("SELECT id, table_name, row_id, content, created_at FROM notes WHERE id = ?", id)
+	var n TudoNote
+	if err := row.Scan(&n.ID, &n.TableName, &n.RowID, &n.Content, &n.CreatedAt); err != nil {
+		return TudoNote{}, err
+	}
+	return n, nil
+}
+
+func GetForItem(db *sql.DB, table string, rowID uint32) ([]TudoNote, error) {
+	rows, err := db.Query("SELECT id, table_name, row_id, content, created_at FROM notes WHERE table_name = ? AND row_id = ? ORDER BY id", table, rowID)
+	if err != nil {
+		return []TudoNote{}, err
+	}
+	defer rows.Close()
+
+	var noteList []TudoNote
+	for rows.Next() {
+		var n TudoNote
+		if err := rows.Scan(&n.ID, &n.TableName, &n.RowID, &n.Content, &n.CreatedAt); err != nil {
+			return []TudoNote{}, err
+		}
+		noteList = append(noteList, n)
+	}
+	return noteList, nil
+}
+
+func Update(db *sql.DB, id uint32, content string) error {
+	if _, err := db.Exec("UPDATE notes SET content = ? WHERE id = ?", content, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Delete(db *sql.DB, id uint32) error {
+	if _, err := db.Exec("DELETE FROM notes WHERE id = ?", id); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Move reattaches the notes of one row to another, used when an item is
+// turned into an item of a different kind.
+func Move(db *sql.DB, fromTable string, fromID uint32, toTable string, toID uint32) error {
+	if _, err := db.Exec("UPDATE notes SET table_name = ?, row_id = ? WHERE table_name = ? AND row_id = ?", toTable, toID, fromTable, fromID); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package people
+
+import (
+	"database/sql"
+	"errors"
+)
+
+type TudoPerson struct {
+	ID        uint32
+	Name      string
+	CreatedAt string
+}
+
+func New(db *sql.DB, name string) (uint32, error) {
+	res, err := db.Exec("INSERT INTO people (id, name, created_at) VALUES (NULL, ?, date())", name)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
+}
+
+func NameExists(db *sql.DB, name string) (bool, uint32, error) {
+	row := db.QueryRow("SELECT id FROM people WHERE name = ?", name)
+	var id uint32
+	err := row.Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, 0, nil
+	} else if err != nil {
+		return false, 0, err
+	}
+
+	return true, id, nil
+}
+
+func Get(db *sql.DB, id uint32) (TudoPerson, error) {
+	row := db.QueryRow("SELECT id, name, created_at FROM people WHERE id = ?", id)
+	var p TudoPerson
+	if err := row.Scan(&p.ID, &p.Name, &p.CreatedAt); err != nil {
+		return TudoPerson{}, err
+	}
+	return p, nil
+}
+
+// GetAll returns every person ordered by name.
+func GetAll(db *sql.DB) ([]TudoPerson, error) {
+	rows, err := db.Query("SELECT id, name, created_at FROM people ORDER BY name")
+	if err != nil {
+		return []TudoPerson{}, err
+	}
+	defer rows.Close()
+
+	var people []TudoPerson
+	for rows.Next() {
+		var p TudoPerson
+		if err := rows.Scan(&p.ID, &p.Name, &p.CreatedAt); err != nil {
+			return []TudoPerson{}, err
+		}
+		people = append(people, p)
+	}
+	return people, nil
+}
+
+// TudoAgendaCount is how many open items concern a person.
+type TudoAgendaCount struct {
+	Waiting int
+	Agenda  int
+}
+
+// Counts returns the number of unfinished waiting-for items and tasks
+// linked to each person, by person id.
+func Counts(db *sql.DB) (map[uint32]TudoAgendaCount, error) {
+	rows, err := db.Query(`
+SELECT p.id,
+  (SELECT COUNT(*) FROM waiting w WHERE w.person_id = p.id AND w.done = 0),
+  (SELECT COUNT(*) FROM tasks t WHERE t.person_id = p.id AND t.done = 0)
+FROM people p`)
+	if err != nil {
+		return map[uint32]TudoAgendaCount{}, err
+	}
+	defer rows.Close()
+
+	counts := make(map[uint32]TudoAgendaCount)
+	for rows.Next() {
+		var id uint32
+		var c TudoAgendaCount
+		if err := rows.Scan(&id, &c.Waiting, &c.Agenda); err != nil {
+			return map[uint32]TudoAgendaCount{}, err
+		}
+		counts[id] = c
+	}
+	return counts, nil
+}
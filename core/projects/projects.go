@@ -3,7 +3,11 @@ package projects
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"tudo/core/tasks"
 )
 
 type TudoProject struct {
@@ -12,13 +16,66 @@ type TudoProject struct {
 	Done       bool
 	CreatedAt  string
 	FinishedAt *string
+	// Sequential projects expose only their first unfinished task as next
+	// action, parallel ones expose all of them.
+	Sequential bool
+	// Outcome describes what done looks like, Purpose why the project
+	// matters.
+	Outcome *string
+	Purpose *string
+	// AreaID links the project to the area of responsibility it belongs to.
+	AreaID *uint32
+	// ParentID is set on sub-projects.
+	ParentID *uint32
+	// ArchivedAt is set on archived projects, which are also done so they
+	// stay out of every active listing.
+	ArchivedAt *string
 }
 
-func New(db *sql.DB, content string) error {
-	if _, err := db.Exec("INSERT INTO projects (id, content, done, created_at) VALUES (NULL, ?, 0, date())", content); err != nil {
-		return err
+// TudoReference is a link or file path to reference material of a project.
+type TudoReference struct {
+	ID        uint32
+	ProjectID uint32
+	Content   string
+	CreatedAt string
+}
+
+// projectColumns are the columns read by scanProject, in order.
+var projectColumns = []string{"id", "content", "done", "created_at", "finished_at", "sequential", "outcome", "purpose", "area_id", "parent_id", "archived_at"}
+
+var projectSelect = "SELECT " + strings.Join(projectColumns, ", ")
+
+// selectProjectAs is projectSelect for queries that join projects under the
+// given alias.
+func selectProjectAs(alias string) string {
+	columns := make([]string, len(projectColumns))
+	for i, c := range projectColumns {
+		columns[i] = alias + "." + c
 	}
-	return nil
+	return "SELECT " + strings.Join(columns, ", ")
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanProject reads the columns of projectSelect into p, followed by any
+// extra columns the query selects after them.
+func scanProject(s scanner, p *TudoProject, extra ...any) error {
+	dest := []any{&p.ID, &p.Content, &p.Done, &p.CreatedAt, &p.FinishedAt, &p.Sequential, &p.Outcome, &p.Purpose, &p.AreaID, &p.ParentID, &p.ArchivedAt}
+	return s.Scan(append(dest, extra...)...)
+}
+
+func New(db *sql.DB, project TudoProject) (uint32, error) {
+	res, err := db.Exec("INSERT INTO projects (id, content, done, created_at, sequential, outcome, purpose, area_id, parent_id) VALUES (NULL, ?, 0, date(), ?, ?, ?, ?, ?)", project.Content, project.Sequential, project.Outcome, project.Purpose, project.AreaID, project.ParentID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
 }
 
 func ContentExists(db *sql.DB, content string) (bool, uint32, error) {
@@ -34,11 +91,37 @@ func ContentExists(db *sql.DB, content string) (bool, uint32, error) {
 	return true, id, nil
 }
 
+func IDExists(db *sql.DB, id uint32) (bool, error) {
+	row := db.QueryRow("SELECT id FROM projects WHERE id = ?", id)
+	var pID uint32
+	if err := row.Scan(&pID); errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Find returns the id of the project with the given name in any state,
+// preferring an active one over the most recently created finished one.
+func Find(db *sql.DB, content string) (bool, uint32, error) {
+	row := db.QueryRow("SELECT id FROM projects WHERE content = ? ORDER BY done, id DESC LIMIT 1", content)
+	var id uint32
+	err := row.Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, 0, nil
+	} else if err != nil {
+		return false, 0, err
+	}
+
+	return true, id, nil
+}
+
 func Get(db *sql.DB, id uint32) (TudoProject, error) {
-	row := db.QueryRow("SELECT id, content, done, created_at, finished_at FROM projects WHERE id = ?", id)
+	row := db.QueryRow(projectSelect+" FROM projects WHERE id = ?", id)
 
 	var p TudoProject
-	err := row.Scan(&p.ID, &p.Content, &p.Done, &p.CreatedAt, &p.FinishedAt)
+	err := scanProject(row, &p)
 	if err != nil {
 		return TudoProject{}, err
 	}
@@ -47,7 +130,16 @@ func Get(db *sql.DB, id uint32) (TudoProject, error) {
 }
 
 func GetActive(db *sql.DB) ([]TudoProject, error) {
-	rows, err := db.Query("SELECT id, content, done, created_at FROM projects WHERE done = 0")
+	return query(db, projectSelect+" FROM projects WHERE done = 0")
+}
+
+// GetAll returns every project, finished and archived ones included.
+func GetAll(db *sql.DB) ([]TudoProject, error) {
+	return query(db, projectSelect+" FROM projects ORDER BY id")
+}
+
+func query(db *sql.DB, query string, args ...any) ([]TudoProject, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return []TudoProject{}, err
 	}
@@ -56,7 +148,7 @@ func GetActive(db *sql.DB) ([]TudoProject, error) {
 	var projects []TudoProject
 	for rows.Next() {
 		var p TudoProject
-		if err := rows.Scan(&p.ID, &p.Content, &p.Done, &p.CreatedAt); err != nil {
+		if err := scanProject(rows, &p); err != nil {
 			return []TudoProject{}, err
 		}
 		projects = append(projects, p)
@@ -64,8 +156,87 @@ func GetActive(db *sql.DB) ([]TudoProject, error) {
 	return projects, nil
 }
 
-func Done(db *sql.DB, id uint32) error {
-	if _, err := db.Exec("UPDATE projects SET done = 1, finished_at = date() WHERE id = ? AND done = 0", id); err != nil {
+// Update overwrites the editable fields of the project with the given id.
+// Moving a project below one of its own sub-projects fails with
+// ErrProjectCycle.
+func Update(db *sql.DB, project TudoProject) error {
+	if project.ParentID != nil {
+		if err := checkParent(db, project.ID, *project.ParentID); err != nil {
+			return err
+		}
+	}
+	if _, err := db.Exec("UPDATE projects SET sequential = ?, outcome = ?, purpose = ?, area_id = ?, parent_id = ? WHERE id = ?", project.Sequential, project.Outcome, project.Purpose, project.AreaID, project.ParentID, project.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func AddReference(db *sql.DB, projectID uint32, content string) error {
+	if _, err := db.Exec("INSERT INTO project_references (id, project_id, content, created_at) VALUES (NULL, ?, ?, date())", projectID, content); err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveReference(db *sql.DB, projectID, id uint32) error {
+	if _, err := db.Exec("DELETE FROM project_references WHERE id = ? AND project_id = ?", id, projectID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetReferences(db *sql.DB, projectID uint32) ([]TudoReference, error) {
+	rows, err := db.Query("SELECT id, project_id, content, created_at FROM project_references WHERE project_id = ? ORDER BY id", projectID)
+	if err != nil {
+		return []TudoReference{}, err
+	}
+	defer rows.Close()
+
+	var references []TudoReference
+	for rows.Next() {
+		var r TudoReference
+		if err := rows.Scan(&r.ID, &r.ProjectID, &r.Content, &r.CreatedAt); err != nil {
+			return []TudoReference{}, err
+		}
+		references = append(references, r)
+	}
+	return references, nil
+}
+
+func Rename(db *sql.DB, id uint32, content string) error {
+	if _, err := db.Exec("UPDATE projects SET content = ? WHERE id = ?", content, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reopen makes a finished or archived project active again.
+func Reopen(db *sql.DB, id uint32) error {
+	if _, err := db.Exec("UPDATE projects SET done = 0, finished_at = NULL, archived_at = NULL WHERE id = ?", id); err != nil {
+		return err
+	}
+	return nil
+}
+
+var ErrArchived error = errors.New("Project is already archived")
+
+// Archive hides a project from every listing but `projects --archived`
+// while keeping it and its tasks. Archiving it again fails with ErrArchived.
+func Archive(db *sql.DB, id uint32) error {
+	res, err := db.Exec("UPDATE projects SET done = 1, archived_at = date() WHERE id = ? AND archived_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrArchived
+	}
+	return nil
+}
+
+func SetSequential(db *sql.DB, id uint32, sequential bool) error {
+	if _, err := db.Exec("UPDATE projects SET sequential = ? WHERE id = ?", sequential, id); err != nil {
 		return err
 	}
 	return nil
@@ -96,3 +267,114 @@ func Review(db *sql.DB, thresh time.Time) ([]TudoProject, error) {
 
 	return projects, nil
 }
+
+// lastActivity is the date of the latest change to a project `p`: its
+// creation, the creation or completion of one of its tasks, a note on it or
+// an action logged on it or its tasks.
+const lastActivity = `MAX(
+  p.created_at,
+  COALESCE((SELECT MAX(MAX(t.created_at, COALESCE(t.finished_at, ''))) FROM tasks t WHERE t.project_id = p.id), ''),
+  COALESCE((SELECT MAX(date(n.created_at)) FROM notes n WHERE n.table_name = 'projects' AND n.row_id = p.id), ''),
+  COALESCE((SELECT MAX(date(a.created_at)) FROM action_log a
+    WHERE (a.table_name = 'projects' AND a.row_id = p.id)
+    OR (a.table_name = 'tasks' AND a.row_id IN (SELECT id FROM tasks WHERE project_id = p.id))), '')
+)`
+
+// TudoStalled is an active project that needs attention in the weekly
+// review, with every reason it was flagged for.
+type TudoStalled struct {
+	Project      TudoProject
+	Reasons      []string
+	LastActivity string
+}
+
+// Stalled finds the active projects without any unfinished task, the ones
+// whose unfinished tasks are all blocked or deferred to a later date, and
+// the ones without any activity in the last idleDays days.
+func Stalled(db *sql.DB, idleDays int) ([]TudoStalled, error) {
+	// Only the first unfinished undated task of a sequential project can be
+	// acted on, as in tasks.GetProjectNextActions; dated tasks are calendar
+	// tasks and count once they are due.
+	actionable := `t.done = 0 AND (t.due IS NULL OR t.due <= date('now', 'localtime')) AND NOT ` + tasks.BlockedSQL("t") + `
+    AND (p.sequential = 0 OR t.due IS NOT NULL
+      OR t.id = (SELECT f.id FROM tasks f WHERE f.project_id = p.id AND f.done = 0 AND f.due IS NULL ORDER BY f.position, f.id LIMIT 1))`
+	rows, err := db.Query(selectProjectAs("p") + `,
+  NOT EXISTS (SELECT 1 FROM tasks t WHERE t.project_id = p.id AND t.done = 0),
+  NOT EXISTS (SELECT 1 FROM tasks t WHERE t.project_id = p.id AND ` + actionable + `),
+  ` + lastActivity + `
+FROM projects p WHERE p.done = 0 ORDER BY p.id`)
+	if err != nil {
+		return []TudoStalled{}, err
+	}
+	defer rows.Close()
+
+	thresh := time.Now().AddDate(0, 0, -idleDays).Format("2006-01-02")
+	var stalled []TudoStalled
+	for rows.Next() {
+		var s TudoStalled
+		var noTasks, noActionable bool
+		if err := scanProject(rows, &s.Project, &noTasks, &noActionable, &s.LastActivity); err != nil {
+			return []TudoStalled{}, err
+		}
+
+		if noTasks {
+			s.Reasons = append(s.Reasons, "no remaining tasks")
+		} else if noActionable {
+			s.Reasons = append(s.Reasons, "only blocked or deferred tasks")
+		}
+		if s.LastActivity < thresh {
+			s.Reasons = append(s.Reasons, fmt.Sprint("untouched for more than ", idleDays, " days"))
+		}
+		if len(s.Reasons) > 0 {
+			stalled = append(stalled, s)
+		}
+	}
+	return stalled, nil
+}
+
+// TudoSummary is the progress and health of a project.
+type TudoSummary struct {
+	Open         int
+	Finished     int
+	Overdue      int
+	NextDue      *string
+	LastActivity string
+	AgeDays      int
+}
+
+// Percent is the share of the project's tasks that are finished.
+func (s TudoSummary) Percent() int {
+	if s.Open+s.Finished == 0 {
+		return 0
+	}
+	return s.Finished * 100 / (s.Open + s.Finished)
+}
+
+// Summaries returns the summary of every project by id.
+func Summaries(db *sql.DB) (map[uint32]TudoSummary, error) {
+	rows, err := db.Query(`
+SELECT p.id,
+  COALESCE(SUM(t.done = 0), 0),
+  COALESCE(SUM(t.done = 1 AND t.cancelled = 0), 0),
+  COALESCE(SUM(t.done = 0 AND t.due < date('now', 'localtime')), 0),
+  MIN(CASE WHEN t.done = 0 AND t.due >= date('now', 'localtime') THEN t.due END),
+  ` + lastActivity + `,
+  CAST(julianday(date('now', 'localtime')) - julianday(p.created_at) AS INTEGER)
+FROM projects p LEFT JOIN tasks t ON t.project_id = p.id
+GROUP BY p.id`)
+	if err != nil {
+		return map[uint32]TudoSummary{}, err
+	}
+	defer rows.Close()
+
+	summaries := make(map[uint32]TudoSummary)
+	for rows.Next() {
+		var id uint32
+		var s TudoSummary
+		if err := rows.Scan(&id, &s.Open, &s.Finished, &s.Overdue, &s.NextDue, &s.LastActivity, &s.AgeDays); err != nil {
+			return map[uint32]TudoSummary{}, err
+		}
+		summaries[id] = s
+	}
+	return summaries, nil
+}
@@ -0,0 +1,229 @@
+package projects
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"tudo/core/log"
+)
+
+var ErrProjectCycle error = errors.New("A project cannot be its own sub-project")
+
+var ErrOpenSubprojects error = errors.New("Project still has open sub-projects")
+
+var ErrOpenTasks error = errors.New("Project still has open tasks")
+
+var ErrMoveTarget error = errors.New("Tasks can only be moved to another active project")
+
+// What happens to the open tasks of the projects being finished.
+const (
+	TasksComplete = "complete"
+	TasksMove     = "move"
+	TasksNext     = "next"
+	TasksCancel   = "cancel"
+)
+
+// TudoResolution is what to do with the open tasks of a finished project,
+// one of the Tasks constants, with the project to move them to for
+// TasksMove.
+type TudoResolution struct {
+	Action    string
+	ProjectID uint32
+}
+
+// TudoProjectNode is a project within a project tree, with the task counts
+// of the project and all of its sub-projects rolled up.
+type TudoProjectNode struct {
+	Project  TudoProject
+	Depth    int
+	Open     int
+	Finished int
+}
+
+// subtreeSQL selects the ids and depths of the project bound to the first
+// parameter and all of its descendants, each child after its parent.
+const subtreeSQL = `
+WITH RECURSIVE tree(id, depth, path) AS (
+  SELECT id, 0, printf('%010d', id) FROM projects WHERE id = ?
+  UNION ALL
+  SELECT p.id, t.depth + 1, t.path || '/' || printf('%010d', p.id)
+  FROM projects p JOIN tree t ON p.parent_id = t.id
+)`
+
+// Tree returns the project with the given id followed by all of its
+// sub-projects in tree order.
+func Tree(db *sql.DB, id uint32) ([]TudoProjectNode, error) {
+	rows, err := db.Query(subtreeSQL+selectProjectAs("p")+`, tree.depth,
+  (SELECT COUNT(*) FROM tasks t WHERE t.project_id = p.id AND t.done = 0),
+  (SELECT COUNT(*) FROM tasks t WHERE t.project_id = p.id AND t.done = 1)
+FROM tree JOIN projects p ON p.id = tree.id ORDER BY tree.path`, id)
+	if err != nil {
+		return []TudoProjectNode{}, err
+	}
+	defer rows.Close()
+
+	var nodes []TudoProjectNode
+	for rows.Next() {
+		var n TudoProjectNode
+		if err := scanProject(rows, &n.Project, &n.Depth, &n.Open, &n.Finished); err != nil {
+			return []TudoProjectNode{}, err
+		}
+		nodes = append(nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return []TudoProjectNode{}, err
+	}
+
+	// Children follow their parent, so walking backwards adds every
+	// subtree's counts to its parent before the parent is added to its own.
+	index := make(map[uint32]int)
+	for i, n := range nodes {
+		index[n.Project.ID] = i
+	}
+	for i := len(nodes) - 1; i > 0; i-- {
+		parent := &nodes[index[*nodes[i].Project.ParentID]]
+		parent.Open += nodes[i].Open
+		parent.Finished += nodes[i].Finished
+	}
+	return nodes, nil
+}
+
+// checkParent returns ErrProjectCycle when parentID is the project itself
+// or one of its sub-projects.
+func checkParent(db *sql.DB, id, parentID uint32) error {
+	row := db.QueryRow(subtreeSQL+" SELECT COUNT(*) FROM tree WHERE id = ?", id, parentID)
+	var cnt int
+	if err := row.Scan(&cnt); err != nil {
+		return err
+	}
+	if cnt > 0 {
+		return ErrProjectCycle
+	}
+	return nil
+}
+
+// Done finishes a project. When it still has open sub-projects it fails
+// with ErrOpenSubprojects, unless cascade is set and the whole subtree is
+// finished. Open tasks of the finished projects are resolved as given, or
+// make it fail with ErrOpenTasks without a resolution. Either everything is
+// finished and logged or nothing is. Completed and cancelled tasks are
+// logged before the projects, the project itself last, so that undo
+// restores the project first and its tasks after. The ids of the projects
+// that were finished are returned.
+func Done(db *sql.DB, id uint32, cascade bool, resolution TudoResolution) ([]uint32, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return []uint32{}, err
+	}
+	defer tx.Rollback()
+
+	finished, err := queryIDs(tx, subtreeSQL+" SELECT p.id FROM tree JOIN projects p ON p.id = tree.id WHERE p.done = 0 ORDER BY tree.path", id)
+	if err != nil {
+		return []uint32{}, err
+	}
+
+	if len(finished) > 1 && !cascade {
+		return []uint32{}, ErrOpenSubprojects
+	}
+	if len(finished) > 0 {
+		if err := resolveTasks(tx, finished, resolution); err != nil {
+			return []uint32{}, err
+		}
+	}
+	for _, pID := range finished {
+		if _, err := tx.Exec("UPDATE projects SET done = 1, finished_at = date() WHERE id = ?", pID); err != nil {
+			return []uint32{}, err
+		}
+	}
+	for i := len(finished) - 1; i >= 0; i-- {
+		if err := log.New(tx, "projects", finished[i]); err != nil {
+			return []uint32{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return []uint32{}, err
+	}
+	return finished, nil
+}
+
+func queryIDs(tx *sql.Tx, query string, args ...any) ([]uint32, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return []uint32{}, err
+	}
+	defer rows.Close()
+
+	var ids []uint32
+	for rows.Next() {
+		var id uint32
+		if err := rows.Scan(&id); err != nil {
+			return []uint32{}, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return []uint32{}, err
+	}
+	return ids, nil
+}
+
+// resolveTasks applies the resolution to the open tasks of the projects and
+// records it in the history of each task.
+func resolveTasks(tx *sql.Tx, projectIDs []uint32, resolution TudoResolution) error {
+	args := make([]any, len(projectIDs))
+	for i, id := range projectIDs {
+		args[i] = id
+	}
+	taskIDs, err := queryIDs(tx, "SELECT id FROM tasks WHERE done = 0 AND project_id IN (?"+strings.Repeat(", ?", len(projectIDs)-1)+") ORDER BY position, id", args...)
+	if err != nil {
+		return err
+	}
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	var update, action string
+	switch resolution.Action {
+	case TasksComplete:
+		update, action = "UPDATE tasks SET done = 1, finished_at = date() WHERE id = ?1", "done"
+	case TasksCancel:
+		update, action = "UPDATE tasks SET done = 1, cancelled = 1, finished_at = date() WHERE id = ?1", "cancelled"
+	case TasksNext:
+		update, action = "UPDATE tasks SET project_id = NULL, position = NULL WHERE id = ?1", "moved to next actions"
+	case TasksMove:
+		var done bool
+		if err := tx.QueryRow("SELECT done FROM projects WHERE id = ?", resolution.ProjectID).Scan(&done); errors.Is(err, sql.ErrNoRows) {
+			return ErrMoveTarget
+		} else if err != nil {
+			return err
+		}
+		for _, id := range projectIDs {
+			if done || id == resolution.ProjectID {
+				return ErrMoveTarget
+			}
+		}
+		// Moved tasks keep their order after the tasks of the target.
+		update = "UPDATE tasks SET project_id = ?2, position = (SELECT COALESCE(MAX(position), 0) + 1 FROM tasks WHERE project_id = ?2) WHERE id = ?1"
+		action = fmt.Sprint("moved to project ", resolution.ProjectID)
+	default:
+		return ErrOpenTasks
+	}
+
+	for _, taskID := range taskIDs {
+		if _, err := tx.Exec(update, taskID, resolution.ProjectID); err != nil {
+			return err
+		}
+		if err := log.NewAction(tx, "tasks", taskID, action+" (project finished)"); err != nil {
+			return err
+		}
+		// Completed and cancelled tasks can be reopened with undo.
+		if resolution.Action == TasksComplete || resolution.Action == TasksCancel {
+			if err := log.New(tx, "tasks", taskID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
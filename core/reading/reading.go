@@ -0,0 +1,87 @@
+package reading
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// TudoReading is an article, book or document on the reading list.
+type TudoReading struct {
+	ID      uint32
+	Title   string
+	URL     *string
+	Source  *string
+	Minutes *uint32
+	Done    bool
+	// ReadAt is when the item was marked as read.
+	ReadAt    *string
+	CreatedAt string
+}
+
+const readingSelect = "SELECT id, title, url, source, minutes, done, read_at, created_at"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReading(s scanner, r *TudoReading) error {
+	return s.Scan(&r.ID, &r.Title, &r.URL, &r.Source, &r.Minutes, &r.Done, &r.ReadAt, &r.CreatedAt)
+}
+
+func New(db *sql.DB, r TudoReading) (uint32, error) {
+	res, err := db.Exec("INSERT INTO reading (id, title, url, source, minutes, done, created_at) VALUES (NULL, ?, ?, ?, ?, 0, date())", r.Title, r.URL, r.Source, r.Minutes)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
+}
+
+func IDExists(db *sql.DB, id uint32) (bool, error) {
+	row := db.QueryRow("SELECT id FROM reading WHERE id = ?", id)
+	var rID uint32
+	if err := row.Scan(&rID); errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func Get(db *sql.DB, id uint32) (TudoReading, error) {
+	row := db.QueryRow(readingSelect+" FROM reading WHERE id = ?", id)
+	var r TudoReading
+	if err := scanReading(row, &r); err != nil {
+		return TudoReading{}, err
+	}
+	return r, nil
+}
+
+// GetUnread returns the reading list, oldest first.
+func GetUnread(db *sql.DB) ([]TudoReading, error) {
+	rows, err := db.Query(readingSelect + " FROM reading WHERE done = 0 ORDER BY created_at, id")
+	if err != nil {
+		return []TudoReading{}, err
+	}
+	defer rows.Close()
+
+	var list []TudoReading
+	for rows.Next() {
+		var r TudoReading
+		if err := scanReading(rows, &r); err != nil {
+			return []TudoReading{}, err
+		}
+		list = append(list, r)
+	}
+	return list, nil
+}
+
+func Done(db *sql.DB, id uint32) error {
+	if _, err := db.Exec("UPDATE reading SET done = 1, read_at = date() WHERE id = ?", id); err != nil {
+		return err
+	}
+	return nil
+}
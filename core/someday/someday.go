@@ -3,6 +3,9 @@ package someday
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+
+	"tudo/core/log"
 )
 
 type TudoSomeday struct {
@@ -10,13 +13,32 @@ type TudoSomeday struct {
 	Content   string
 	Done      bool
 	CreatedAt string
+	// Category groups items such as books, trips or skills.
+	Category *string
+	// ReviewedAt is when the item was last kept in a someday review.
+	ReviewedAt *string
 }
 
-func New(db *sql.DB, content string) error {
-	if _, err := db.Exec("INSERT INTO someday (id, content, done, created_at) VALUES (NULL, ?, 0, date())", content); err != nil {
-		return err
+const somedaySelect = "SELECT id, content, done, created_at, category, reviewed_at"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSomeday(s scanner, item *TudoSomeday) error {
+	return s.Scan(&item.ID, &item.Content, &item.Done, &item.CreatedAt, &item.Category, &item.ReviewedAt)
+}
+
+func New(db *sql.DB, item TudoSomeday) (uint32, error) {
+	res, err := db.Exec("INSERT INTO someday (id, content, done, created_at, category) VALUES (NULL, ?, 0, date(), ?)", item.Content, item.Category)
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
 }
 
 func ContentExists(db *sql.DB, content string) (bool, uint32, error) {
@@ -46,50 +68,117 @@ func IDExists(db *sql.DB, id uint32) (bool, error) {
 }
 
 func Get(db *sql.DB, id uint32) (TudoSomeday, error) {
-	row := db.QueryRow("SELECT id, content, created_at, done FROM someday WHERE id = ? AND done = 0", id)
-	var task TudoSomeday
-	err := row.Scan(&task.ID, &task.Content, &task.CreatedAt, &task.Done)
-	if err != nil {
+	row := db.QueryRow(somedaySelect+" FROM someday WHERE id = ?", id)
+	var item TudoSomeday
+	if err := scanSomeday(row, &item); err != nil {
 		return TudoSomeday{}, err
 	}
-	return task, nil
+	return item, nil
 }
 
 func GetActive(db *sql.DB) ([]TudoSomeday, error) {
-	rows, err := db.Query("SELECT id, content, done, created_at FROM someday WHERE done = 0")
+	return query(db, somedaySelect+" FROM someday WHERE done = 0")
+}
+
+// DueForReview returns the unfinished items that were neither created nor
+// reviewed in the last given number of weeks, the longest unseen first.
+func DueForReview(db *sql.DB, weeks int) ([]TudoSomeday, error) {
+	return query(db, somedaySelect+` FROM someday
+WHERE done = 0 AND COALESCE(reviewed_at, created_at) <= date('now', 'localtime', ?)
+ORDER BY COALESCE(reviewed_at, created_at), id`, fmt.Sprint("-", weeks*7, " days"))
+}
+
+func query(db *sql.DB, query string, args ...any) ([]TudoSomeday, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return []TudoSomeday{}, err
 	}
 	defer rows.Close()
 
-	var tasks []TudoSomeday
+	var items []TudoSomeday
 	for rows.Next() {
-		var s TudoSomeday
-		if err := rows.Scan(&s.ID, &s.Content, &s.Done, &s.CreatedAt); err != nil {
+		var item TudoSomeday
+		if err := scanSomeday(rows, &item); err != nil {
 			return []TudoSomeday{}, err
 		}
-		tasks = append(tasks, s)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Update overwrites the editable fields of the item with the given id.
+func Update(db *sql.DB, item TudoSomeday) error {
+	if _, err := db.Exec("UPDATE someday SET content = ?, category = ? WHERE id = ?", item.Content, item.Category, item.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reviewed records that the item was looked at and kept today.
+func Reviewed(db *sql.DB, id uint32) error {
+	if _, err := db.Exec("UPDATE someday SET reviewed_at = date() WHERE id = ?", id); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delete removes an item along with its notes and tags.
+func Delete(db *sql.DB, id uint32) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	return tasks, nil
+	for _, q := range []string{
+		"DELETE FROM notes WHERE table_name = 'someday' AND row_id = ?",
+		"DELETE FROM item_tags WHERE table_name = 'someday' AND row_id = ?",
+		"DELETE FROM action_log WHERE table_name = 'someday' AND row_id = ?",
+		"DELETE FROM someday WHERE id = ?",
+	} {
+		if _, err := tx.Exec(q, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func Read(db *sql.DB) ([]TudoSomeday, error) {
-	rows, err := db.Query("SELECT id, tasks.content, done, tasks.created_at FROM someday WHERE done = 0 AND content LIKE '%read%'")
+// Promote turns an item into a project of the same name, moving its notes
+// over, copying its tags and marking the item done, all or nothing. The id
+// of the new project is returned.
+func Promote(db *sql.DB, id uint32) (uint32, error) {
+	tx, err := db.Begin()
 	if err != nil {
-		return []TudoSomeday{}, err
+		return 0, err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var tasks []TudoSomeday
-	for rows.Next() {
-		var task TudoSomeday
-		if err := rows.Scan(&task.ID, &task.Content, &task.Done, &task.CreatedAt); err != nil {
-			return []TudoSomeday{}, err
+	res, err := tx.Exec("INSERT INTO projects (id, content, done, created_at) SELECT NULL, content, 0, date() FROM someday WHERE id = ?", id)
+	if err != nil {
+		return 0, err
+	}
+	projectID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, q := range []string{
+		"UPDATE notes SET table_name = 'projects', row_id = ?1 WHERE table_name = 'someday' AND row_id = ?2",
+		"INSERT OR IGNORE INTO item_tags (tag_id, table_name, row_id) SELECT tag_id, 'projects', ?1 FROM item_tags WHERE table_name = 'someday' AND row_id = ?2",
+		"UPDATE someday SET done = 1 WHERE id = ?2",
+	} {
+		if _, err := tx.Exec(q, projectID, id); err != nil {
+			return 0, err
 		}
-		tasks = append(tasks, task)
 	}
-	return tasks, nil
+	if err := log.NewAction(tx, "projects", uint32(projectID), fmt.Sprint("promoted from someday ", id)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return uint32(projectID), nil
 }
 
 func Done(db *sql.DB, id uint32) error {
@@ -0,0 +1,80 @@
+package tags
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Tags are attached to rows of any item table (tasks, projects, waiting,
+// someday and capture), identified by the table name and row id.
+
+// Normalize strips the leading `#` and lowercases a tag.
+func Normalize(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// Parse splits a comma separated list of tags, dropping empty entries.
+func Parse(s string) []string {
+	var tagList []string
+	for _, t := range strings.Split(s, ",") {
+		if t = Normalize(t); t != "" {
+			tagList = append(tagList, t)
+		}
+	}
+	return tagList
+}
+
+func Add(db *sql.DB, table string, rowID uint32, tag string) error {
+	if _, err := db.Exec("INSERT OR IGNORE INTO tags (id, content) VALUES (NULL, ?)", tag); err != nil {
+		return err
+	}
+	if _, err := db.Exec("INSERT OR IGNORE INTO item_tags (tag_id, table_name, row_id) SELECT id, ?, ? FROM tags WHERE content = ?", table, rowID, tag); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Remove(db *sql.DB, table string, rowID uint32, tag string) error {
+	if _, err := db.Exec("DELETE FROM item_tags WHERE table_name = ? AND row_id = ? AND tag_id IN (SELECT id FROM tags WHERE content = ?)", table, rowID, tag); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Get(db *sql.DB, table string, rowID uint32) ([]string, error) {
+	rows, err := db.Query("SELECT t.content FROM item_tags i JOIN tags t ON t.id = i.tag_id WHERE i.table_name = ? AND i.row_id = ? ORDER BY t.content", table, rowID)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+
+	var tagList []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return []string{}, err
+		}
+		tagList = append(tagList, tag)
+	}
+	return tagList, nil
+}
+
+// GetAll returns the tags of every tagged row of the given table.
+func GetAll(db *sql.DB, table string) (map[uint32][]string, error) {
+	rows, err := db.Query("SELECT i.row_id, t.content FROM item_tags i JOIN tags t ON t.id = i.tag_id WHERE i.table_name = ? ORDER BY t.content", table)
+	if err != nil {
+		return map[uint32][]string{}, err
+	}
+	defer rows.Close()
+
+	tagMap := make(map[uint32][]string)
+	for rows.Next() {
+		var rowID uint32
+		var tag string
+		if err := rows.Scan(&rowID, &tag); err != nil {
+			return map[uint32][]string{}, err
+		}
+		tagMap[rowID] = append(tagMap[rowID], tag)
+	}
+	return tagMap, nil
+}
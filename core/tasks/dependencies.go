@@ -0,0 +1,121 @@
+package tasks
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var ErrDependencyCycle error = errors.New("Dependency would create a cycle")
+
+// BlockedSQL returns a condition matching rows of the tasks table, referred
+// to by the given name, with at least one unfinished prerequisite.
+func BlockedSQL(table string) string {
+	return "EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks b ON b.id = d.blocked_by WHERE d.task_id = " + table + ".id AND b.done = 0)"
+}
+
+// AddDependency records that taskID cannot start before blockedBy is done.
+func AddDependency(db *sql.DB, taskID, blockedBy uint32) error {
+	if taskID == blockedBy {
+		return ErrDependencyCycle
+	}
+
+	// Walk the prerequisites of blockedBy, if taskID is among them the new
+	// edge would close a cycle.
+	row := db.QueryRow(`
+WITH RECURSIVE prerequisites(id) AS (
+  SELECT blocked_by FROM task_dependencies WHERE task_id = ?
+  UNION
+  SELECT d.blocked_by FROM task_dependencies d JOIN prerequisites p ON d.task_id = p.id
+)
+SELECT COUNT(*) FROM prerequisites WHERE id = ?`, blockedBy, taskID)
+	var cnt int
+	if err := row.Scan(&cnt); err != nil {
+		return err
+	}
+	if cnt > 0 {
+		return ErrDependencyCycle
+	}
+
+	if _, err := db.Exec("INSERT OR IGNORE INTO task_dependencies (task_id, blocked_by) VALUES (?, ?)", taskID, blockedBy); err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveDependency(db *sql.DB, taskID, blockedBy uint32) error {
+	if _, err := db.Exec("DELETE FROM task_dependencies WHERE task_id = ? AND blocked_by = ?", taskID, blockedBy); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetBlockers returns the unfinished prerequisites of a task.
+func GetBlockers(db *sql.DB, taskID uint32) ([]TudoTask, error) {
+	rows, err := db.Query(taskSelect+" FROM tasks WHERE done = 0 AND id IN (SELECT blocked_by FROM task_dependencies WHERE task_id = ?)", taskID)
+	if err != nil {
+		return []TudoTask{}, err
+	}
+	defer rows.Close()
+
+	var blockers []TudoTask
+	for rows.Next() {
+		var t TudoTask
+		if err := scanTask(rows, &t); err != nil {
+			return []TudoTask{}, err
+		}
+		blockers = append(blockers, t)
+	}
+	return blockers, nil
+}
+
+// GetBlocked returns every unfinished task waiting on another one.
+func GetBlocked(db *sql.DB) ([]TudoTask, error) {
+	rows, err := db.Query(taskSelect + " FROM tasks WHERE done = 0 AND " + BlockedSQL("tasks"))
+	if err != nil {
+		return []TudoTask{}, err
+	}
+	defer rows.Close()
+
+	var blocked []TudoTask
+	for rows.Next() {
+		var t TudoTask
+		if err := scanTask(rows, &t); err != nil {
+			return []TudoTask{}, err
+		}
+		blocked = append(blocked, t)
+	}
+	return blocked, nil
+}
+
+func BlockedIDs(db *sql.DB) (map[uint32]bool, error) {
+	blocked, err := GetBlocked(db)
+	if err != nil {
+		return map[uint32]bool{}, err
+	}
+
+	ids := make(map[uint32]bool)
+	for _, t := range blocked {
+		ids[t.ID] = true
+	}
+	return ids, nil
+}
+
+// GetUnblockedBy returns the unfinished tasks depending on the given task
+// that no longer have any unfinished prerequisite.
+func GetUnblockedBy(db *sql.DB, id uint32) ([]TudoTask, error) {
+	rows, err := db.Query(taskSelect+" FROM tasks WHERE done = 0 AND id IN (SELECT task_id FROM task_dependencies WHERE blocked_by = ?) AND NOT "+BlockedSQL("tasks"), id)
+	if err != nil {
+		return []TudoTask{}, err
+	}
+	defer rows.Close()
+
+	var unblocked []TudoTask
+	for rows.Next() {
+		var t TudoTask
+		if err := scanTask(rows, &t); err != nil {
+			return []TudoTask{}, err
+		}
+		unblocked = append(unblocked, t)
+	}
+	return unblocked, nil
+}
@@ -3,6 +3,9 @@ package tasks
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,15 +18,155 @@ type TudoTask struct {
 	Done       bool
 	CreatedAt  string
 	FinishedAt *string
+	// Estimate is the expected effort in minutes.
+	Estimate *uint32
+	Energy   *string
+	// Priority runs from A (highest) to D.
+	Priority *string
+	// Position orders the tasks of a project.
+	Position *uint32
+	// Cancelled tasks are done without having been carried out.
+	Cancelled bool
+	// PersonID puts the task on the agenda of a person.
+	PersonID *uint32
 }
 
-func New(db *sql.DB, content string, projectID *uint32, context *string, due *string) error {
-	if _, err := db.Exec("INSERT INTO tasks (id, content, project_id, context, due, done, created_at, finished_at) VALUES (NULL, ?, ?, ?, ?, 0, date(), NULL)", content, projectID, context, due); err != nil {
+// Energy levels from least to most demanding.
+var EnergyLevels = []string{"low", "medium", "high"}
+
+var Priorities = []string{"A", "B", "C", "D"}
+
+const taskSelect = "SELECT id, content, project_id, context, due, done, created_at, finished_at, estimate, energy, priority, position, cancelled, person_id"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(s scanner, t *TudoTask) error {
+	return s.Scan(&t.ID, &t.Content, &t.ProjectID, &t.Context, &t.Due, &t.Done, &t.CreatedAt, &t.FinishedAt, &t.Estimate, &t.Energy, &t.Priority, &t.Position, &t.Cancelled, &t.PersonID)
+}
+
+func New(db *sql.DB, task TudoTask) (uint32, error) {
+	res, err := db.Exec(`
+INSERT INTO tasks (id, content, project_id, context, due, done, created_at, finished_at, estimate, energy, priority, person_id, position)
+VALUES (NULL, ?1, ?2, ?3, ?4, 0, date(), NULL, ?5, ?6, ?7, ?8, CASE WHEN ?2 IS NULL THEN NULL ELSE (SELECT COALESCE(MAX(position), 0) + 1 FROM tasks WHERE project_id = ?2) END)`,
+		task.Content, task.ProjectID, task.Context, task.Due, task.Estimate, task.Energy, task.Priority, task.PersonID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
+}
+
+// Reorder moves a task to the given 1-based position among the tasks of its
+// project, renumbering the others.
+func Reorder(db *sql.DB, id uint32, position int) error {
+	task, err := Get(db, id)
+	if err != nil {
+		return err
+	}
+	if task.ProjectID == nil {
+		return errors.New("Task is not part of a project")
+	}
+
+	rows, err := db.Query("SELECT id FROM tasks WHERE project_id = ? AND id != ? ORDER BY position, id", *task.ProjectID, id)
+	if err != nil {
+		return err
+	}
+	var order []uint32
+	for rows.Next() {
+		var tID uint32
+		if err := rows.Scan(&tID); err != nil {
+			rows.Close()
+			return err
+		}
+		order = append(order, tID)
+	}
+	rows.Close()
+
+	position = max(1, min(position, len(order)+1))
+	order = append(order[:position-1], append([]uint32{id}, order[position-1:]...)...)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for i, tID := range order {
+		if _, err := tx.Exec("UPDATE tasks SET position = ? WHERE id = ?", i+1, tID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Update overwrites the editable fields of the task with the given id.
+func Update(db *sql.DB, task TudoTask) error {
+	if _, err := db.Exec("UPDATE tasks SET content = ?, context = ?, due = ?, estimate = ?, energy = ?, priority = ?, person_id = ? WHERE id = ?", task.Content, task.Context, task.Due, task.Estimate, task.Energy, task.Priority, task.PersonID, task.ID); err != nil {
 		return err
 	}
 	return nil
 }
 
+// ParseEstimate reads an effort such as `15m`, `1h`, `1h30m` or a plain
+// number of minutes.
+func ParseEstimate(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return uint32(n), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute {
+		return 0, errors.New("Invalid estimate `" + s + "`, expected e.g. `15m` or `1h30m`")
+	}
+	return uint32(d.Minutes()), nil
+}
+
+func FormatEstimate(minutes uint32) string {
+	h, m := minutes/60, minutes%60
+	switch {
+	case h == 0:
+		return fmt.Sprint(m, "m")
+	case m == 0:
+		return fmt.Sprint(h, "h")
+	default:
+		return fmt.Sprint(h, "h", m, "m")
+	}
+}
+
+func ParseEnergy(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, level := range EnergyLevels {
+		if s == level {
+			return s, nil
+		}
+	}
+	return "", errors.New("Invalid energy `" + s + "`, expected one of " + strings.Join(EnergyLevels, ", "))
+}
+
+func ParsePriority(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for _, p := range Priorities {
+		if s == p {
+			return s, nil
+		}
+	}
+	return "", errors.New("Invalid priority `" + s + "`, expected one of " + strings.Join(Priorities, ", "))
+}
+
+// EnergyRank orders energy levels, returning -1 for an unknown level.
+func EnergyRank(energy string) int {
+	for i, level := range EnergyLevels {
+		if energy == level {
+			return i
+		}
+	}
+	return -1
+}
+
 func IDExists(db *sql.DB, id uint32) (bool, error) {
 	row := db.QueryRow("SELECT id FROM tasks WHERE id = ?", id)
 	var taskID uint32
@@ -36,9 +179,9 @@ func IDExists(db *sql.DB, id uint32) (bool, error) {
 }
 
 func Get(db *sql.DB, id uint32) (TudoTask, error) {
-	row := db.QueryRow("SELECT id, content, project_id, context, due, done, created_at, finished_at FROM tasks WHERE id = ?", id)
+	row := db.QueryRow(taskSelect+" FROM tasks WHERE id = ?", id)
 	var task TudoTask
-	err := row.Scan(&task.ID, &task.Content, &task.ProjectID, &task.Context, &task.Due, &task.Done, &task.CreatedAt, &task.FinishedAt)
+	err := scanTask(row, &task)
 	if err != nil {
 		return TudoTask{}, err
 	}
@@ -47,7 +190,41 @@ func Get(db *sql.DB, id uint32) (TudoTask, error) {
 }
 
 func GetActiveNextActions(db *sql.DB) ([]TudoTask, error) {
-	rows, err := db.Query("SELECT id, content, context FROM tasks WHERE done == 0 AND project_id IS NULL AND due IS NULL GROUP BY context")
+	rows, err := db.Query(taskSelect + " FROM tasks WHERE done == 0 AND project_id IS NULL AND due IS NULL ORDER BY context, id")
+	if err != nil {
+		return []TudoTask{}, err
+	}
+	defer rows.Close()
+
+	var nextActions []TudoTask
+	for rows.Next() {
+		var action TudoTask
+		if err := scanTask(rows, &action); err != nil {
+			return []TudoTask{}, err
+		}
+		nextActions = append(nextActions, action)
+	}
+
+	return nextActions, nil
+}
+
+// GetProjectNextActions returns the next actions of every active project:
+// all unfinished and unblocked tasks without a due date of a parallel
+// project, but only the first unfinished one of a sequential project, and
+// only if it is not blocked.
+func GetProjectNextActions(db *sql.DB) ([]TudoTask, error) {
+	rows, err := db.Query(taskSelect + `
+FROM tasks
+WHERE done = 0 AND due IS NULL AND NOT ` + BlockedSQL("tasks") + `
+AND project_id IN (SELECT id FROM projects WHERE done = 0)
+AND (
+  project_id IN (SELECT id FROM projects WHERE sequential = 0)
+  OR id = (
+    SELECT t.id FROM tasks t WHERE t.project_id = tasks.project_id AND t.done = 0 AND t.due IS NULL
+    ORDER BY t.position, t.id LIMIT 1
+  )
+)
+ORDER BY context, position, id`)
 	if err != nil {
 		return []TudoTask{}, err
 	}
@@ -56,7 +233,7 @@ func GetActiveNextActions(db *sql.DB) ([]TudoTask, error) {
 	var nextActions []TudoTask
 	for rows.Next() {
 		var action TudoTask
-		if err := rows.Scan(&action.ID, &action.Content, &action.Context); err != nil {
+		if err := scanTask(rows, &action); err != nil {
 			return []TudoTask{}, err
 		}
 		nextActions = append(nextActions, action)
@@ -65,8 +242,12 @@ func GetActiveNextActions(db *sql.DB) ([]TudoTask, error) {
 	return nextActions, nil
 }
 
+// notArchived matches tasks outside archived projects, which are hidden
+// from every listing along with their project.
+const notArchived = "(project_id IS NULL OR project_id IN (SELECT id FROM projects WHERE archived_at IS NULL))"
+
 func GetTodayCalenderTasks(db *sql.DB) ([]TudoTask, error) {
-	rows, err := db.Query("SELECT id, content, project_id, context, due, done, created_at, finished_at FROM tasks WHERE done = 0 AND due IS NOT NULL")
+	rows, err := db.Query(taskSelect + " FROM tasks WHERE done = 0 AND due IS NOT NULL AND " + notArchived)
 	if err != nil {
 		return []TudoTask{}, err
 	}
@@ -77,7 +258,7 @@ func GetTodayCalenderTasks(db *sql.DB) ([]TudoTask, error) {
 	var tasks []TudoTask
 	for rows.Next() {
 		var task TudoTask
-		if err := rows.Scan(&task.ID, &task.Content, &task.ProjectID, &task.Context, &task.Due, &task.Done, &task.CreatedAt, &task.FinishedAt); err != nil {
+		if err := scanTask(rows, &task); err != nil {
 			return []TudoTask{}, err
 		}
 		if task.Due != nil {
@@ -94,7 +275,7 @@ func GetTodayCalenderTasks(db *sql.DB) ([]TudoTask, error) {
 }
 
 func GetAllCalenderTasks(db *sql.DB) ([]TudoTask, error) {
-	rows, err := db.Query("SELECT id, content, project_id, context, due, done, created_at, finished_at FROM tasks WHERE done = 0 AND due IS NOT NULL")
+	rows, err := db.Query(taskSelect + " FROM tasks WHERE done = 0 AND due IS NOT NULL AND " + notArchived)
 	if err != nil {
 		return []TudoTask{}, err
 	}
@@ -103,7 +284,7 @@ func GetAllCalenderTasks(db *sql.DB) ([]TudoTask, error) {
 	var tasks []TudoTask
 	for rows.Next() {
 		var task TudoTask
-		if err := rows.Scan(&task.ID, &task.Content, &task.ProjectID, &task.Context, &task.Due, &task.Done, &task.CreatedAt, &task.FinishedAt); err != nil {
+		if err := scanTask(rows, &task); err != nil {
 			return []TudoTask{}, err
 		}
 		if task.Due != nil {
@@ -114,7 +295,7 @@ func GetAllCalenderTasks(db *sql.DB) ([]TudoTask, error) {
 }
 
 func GetTodayProjectCalendarTasks(db *sql.DB, projectID uint32) ([]TudoTask, error) {
-	rows, err := db.Query("SELECT id, content, project_id, context, due, done, created_at, finished_at FROM tasks WHERE done = 0 AND due IS NOT NULL AND project_id = ?", projectID)
+	rows, err := db.Query(taskSelect+" FROM tasks WHERE done = 0 AND due IS NOT NULL AND project_id = ?", projectID)
 	if err != nil {
 		return []TudoTask{}, err
 	}
@@ -125,7 +306,7 @@ func GetTodayProjectCalendarTasks(db *sql.DB, projectID uint32) ([]TudoTask, err
 	var tasks []TudoTask
 	for rows.Next() {
 		var task TudoTask
-		if err := rows.Scan(&task.ID, &task.Content, &task.ProjectID, &task.Context, &task.Due, &task.Done, &task.CreatedAt, &task.FinishedAt); err != nil {
+		if err := scanTask(rows, &task); err != nil {
 			return []TudoTask{}, err
 		}
 		if task.Due != nil {
@@ -142,7 +323,7 @@ func GetTodayProjectCalendarTasks(db *sql.DB, projectID uint32) ([]TudoTask, err
 }
 
 func GetAllProjectCalendarTasks(db *sql.DB, projectID uint32) ([]TudoTask, error) {
-	rows, err := db.Query("SELECT id, content, project_id, context, due, done, created_at, finished_at FROM tasks WHERE done = 0 AND due IS NOT NULL AND project_id = ?", projectID)
+	rows, err := db.Query(taskSelect+" FROM tasks WHERE done = 0 AND due IS NOT NULL AND project_id = ?", projectID)
 	if err != nil {
 		return []TudoTask{}, err
 	}
@@ -151,7 +332,7 @@ func GetAllProjectCalendarTasks(db *sql.DB, projectID uint32) ([]TudoTask, error
 	var tasks []TudoTask
 	for rows.Next() {
 		var task TudoTask
-		if err := rows.Scan(&task.ID, &task.Content, &task.ProjectID, &task.Context, &task.Due, &task.Done, &task.CreatedAt, &task.FinishedAt); err != nil {
+		if err := scanTask(rows, &task); err != nil {
 			return []TudoTask{}, err
 		}
 		if task.Due != nil {
@@ -161,8 +342,9 @@ func GetAllProjectCalendarTasks(db *sql.DB, projectID uint32) ([]TudoTask, error
 	return tasks, nil
 }
 
-func GetActiveProjectTasks(db *sql.DB, projectID uint32) ([]TudoTask, error) {
-	rows, err := db.Query("SELECT id, content, project_id, context, due, done, created_at, finished_at FROM tasks WHERE done = 0 AND due IS NULL AND project_id = ?", projectID)
+// GetForPerson returns the unfinished tasks on the agenda of a person.
+func GetForPerson(db *sql.DB, personID uint32) ([]TudoTask, error) {
+	rows, err := db.Query(taskSelect+" FROM tasks WHERE done = 0 AND person_id = ? ORDER BY id", personID)
 	if err != nil {
 		return []TudoTask{}, err
 	}
@@ -171,7 +353,7 @@ func GetActiveProjectTasks(db *sql.DB, projectID uint32) ([]TudoTask, error) {
 	var tasks []TudoTask
 	for rows.Next() {
 		var t TudoTask
-		if err := rows.Scan(&t.ID, &t.Content, &t.ProjectID, &t.Context, &t.Due, &t.Done, &t.CreatedAt, &t.FinishedAt); err != nil {
+		if err := scanTask(rows, &t); err != nil {
 			return []TudoTask{}, err
 		}
 		tasks = append(tasks, t)
@@ -179,8 +361,10 @@ func GetActiveProjectTasks(db *sql.DB, projectID uint32) ([]TudoTask, error) {
 	return tasks, nil
 }
 
-func Read(db *sql.DB) ([]TudoTask, error) {
-	rows, err := db.Query("SELECT id, content, project_id, context, due, done, created_at, finished_at FROM tasks WHERE done = 0 AND content LIKE '%read%'")
+// GetOpenProjectTasks returns every unfinished task of a project, calendar
+// tasks included, in project order.
+func GetOpenProjectTasks(db *sql.DB, projectID uint32) ([]TudoTask, error) {
+	rows, err := db.Query(taskSelect+" FROM tasks WHERE done = 0 AND project_id = ? ORDER BY position, id", projectID)
 	if err != nil {
 		return []TudoTask{}, err
 	}
@@ -188,11 +372,29 @@ func Read(db *sql.DB) ([]TudoTask, error) {
 
 	var tasks []TudoTask
 	for rows.Next() {
-		var task TudoTask
-		if err := rows.Scan(&task.ID, &task.Content, &task.ProjectID, &task.Context, &task.Due, &task.Done, &task.CreatedAt, &task.FinishedAt); err != nil {
+		var t TudoTask
+		if err := scanTask(rows, &t); err != nil {
 			return []TudoTask{}, err
 		}
-		tasks = append(tasks, task)
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+func GetActiveProjectTasks(db *sql.DB, projectID uint32) ([]TudoTask, error) {
+	rows, err := db.Query(taskSelect+" FROM tasks WHERE done = 0 AND due IS NULL AND project_id = ? ORDER BY position, id", projectID)
+	if err != nil {
+		return []TudoTask{}, err
+	}
+	defer rows.Close()
+
+	var tasks []TudoTask
+	for rows.Next() {
+		var t TudoTask
+		if err := scanTask(rows, &t); err != nil {
+			return []TudoTask{}, err
+		}
+		tasks = append(tasks, t)
 	}
 	return tasks, nil
 }
@@ -206,7 +408,7 @@ func Done(db *sql.DB, id uint32) error {
 }
 
 func CountCalendar(db *sql.DB) (int, error) {
-	row := db.QueryRow("SELECT COUNT(*) FROM tasks WHERE done = 0 AND due IS NOT NULL")
+	row := db.QueryRow("SELECT COUNT(*) FROM tasks WHERE done = 0 AND due IS NOT NULL AND " + notArchived)
 	var cnt int
 	err := row.Scan(&cnt)
 	if err != nil {
@@ -226,7 +428,7 @@ func CountNextActions(db *sql.DB) (int, error) {
 }
 
 func CountProjectTasks(db *sql.DB) (int, error) {
-	row := db.QueryRow("SELECT COUNT(*) FROM tasks WHERE done = 0 AND project_id IS NOT NULL AND due IS NULL")
+	row := db.QueryRow("SELECT COUNT(*) FROM tasks WHERE done = 0 AND project_id IS NOT NULL AND due IS NULL AND " + notArchived)
 	var cnt int
 	err := row.Scan(&cnt)
 	if err != nil {
@@ -236,7 +438,7 @@ func CountProjectTasks(db *sql.DB) (int, error) {
 }
 
 func CleanCalendar(db *sql.DB) error {
-	if _, err := db.Exec("UPDATE tasks SET done = 1, finished_at = date() WHERE done = 0 AND due IS NOT NULL"); err != nil {
+	if _, err := db.Exec("UPDATE tasks SET done = 1, finished_at = date() WHERE done = 0 AND due IS NOT NULL AND " + notArchived); err != nil {
 		return err
 	}
 	return nil
@@ -250,14 +452,14 @@ func CleanNextActions(db *sql.DB) error {
 }
 
 func CleanProjectTasks(db *sql.DB) error {
-	if _, err := db.Exec("UPDATE tasks SET done = 1, finished_at = date() WHERE done = 0 AND project_id IS NOT NULL AND due IS NULL"); err != nil {
+	if _, err := db.Exec("UPDATE tasks SET done = 1, finished_at = date() WHERE done = 0 AND project_id IS NOT NULL AND due IS NULL AND " + notArchived); err != nil {
 		return err
 	}
 	return nil
 }
 
 func Review(db *sql.DB, thresh time.Time) (map[time.Time][]TudoTask, error) {
-	rows, err := db.Query("SELECT id, content, project_id, context, due, done, created_at, finished_at FROM tasks WHERE done = 1")
+	rows, err := db.Query(taskSelect + " FROM tasks WHERE done = 1")
 	if err != nil {
 		return map[time.Time][]TudoTask{}, err
 	}
@@ -266,7 +468,7 @@ func Review(db *sql.DB, thresh time.Time) (map[time.Time][]TudoTask, error) {
 	tasksFinishedSinceThreshold := make(map[time.Time][]TudoTask)
 	for rows.Next() {
 		var task TudoTask
-		if err := rows.Scan(&task.ID, &task.Content, &task.ProjectID, &task.Context, &task.Due, &task.Done, &task.CreatedAt, &task.FinishedAt); err != nil {
+		if err := scanTask(rows, &task); err != nil {
 			return map[time.Time][]TudoTask{}, err
 		}
 		if task.FinishedAt != nil {
@@ -285,7 +487,7 @@ func Review(db *sql.DB, thresh time.Time) (map[time.Time][]TudoTask, error) {
 }
 
 func PendingCalendar(db *sql.DB, thresh time.Time) ([]TudoTask, error) {
-	rows, err := db.Query("SELECT id, content, project_id, context, due, done, created_at, finished_at FROM tasks WHERE done = 0 AND due IS NOT NULL")
+	rows, err := db.Query(taskSelect + " FROM tasks WHERE done = 0 AND due IS NOT NULL AND " + notArchived)
 	if err != nil {
 		return []TudoTask{}, err
 	}
@@ -294,7 +496,7 @@ func PendingCalendar(db *sql.DB, thresh time.Time) ([]TudoTask, error) {
 	var pendingTasks []TudoTask
 	for rows.Next() {
 		var task TudoTask
-		if err := rows.Scan(&task.ID, &task.Content, &task.ProjectID, &task.Context, &task.Due, &task.Done, &task.CreatedAt, &task.FinishedAt); err != nil {
+		if err := scanTask(rows, &task); err != nil {
 			return []TudoTask{}, err
 		}
 
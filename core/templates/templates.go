@@ -0,0 +1,158 @@
+package templates
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A template is a plain text file named after the template. Lines starting
+// with `#` are comments, `key: value` lines before the first task set the
+// order, outcome and purpose of the project, and every task is a line
+// starting with `- ` that may contain an `@context` and a due date offset
+// from the start date such as `+3d` or `+2w`:
+//
+//	order: sequential
+//	outcome: Release published
+//	- Freeze the branch @computer
+//	- Write release notes @computer +2d
+//	- Announce the release +1w
+type TudoTemplate struct {
+	Name       string
+	Sequential bool
+	Outcome    *string
+	Purpose    *string
+	Tasks      []TudoTemplateTask
+}
+
+type TudoTemplateTask struct {
+	Content string
+	Context *string
+	// DueOffset is the number of days after the start date the task is due,
+	// nil for tasks without a due date.
+	DueOffset *int
+}
+
+// Ext is the file extension of template files.
+const Ext = ".txt"
+
+// List returns the names of the templates in dir.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	} else if err != nil {
+		return []string{}, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), Ext) {
+			names = append(names, strings.TrimSuffix(e.Name(), Ext))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load reads the template with the given name from dir.
+func Load(dir, name string) (TudoTemplate, error) {
+	f, err := os.Open(filepath.Join(dir, name+Ext))
+	if errors.Is(err, os.ErrNotExist) {
+		return TudoTemplate{}, errors.New("No template `" + name + "` exists in " + dir)
+	} else if err != nil {
+		return TudoTemplate{}, err
+	}
+	defer f.Close()
+
+	return Parse(name, f)
+}
+
+func Parse(name string, r io.Reader) (TudoTemplate, error) {
+	t := TudoTemplate{Name: name}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if content, ok := strings.CutPrefix(line, "- "); ok {
+			task, err := parseTask(content)
+			if err != nil {
+				return TudoTemplate{}, lineError(name, n, err)
+			}
+			t.Tasks = append(t.Tasks, task)
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || len(t.Tasks) > 0 {
+			return TudoTemplate{}, lineError(name, n, errors.New("expected `- <task>`"))
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "order":
+			switch value {
+			case "sequential":
+				t.Sequential = true
+			case "parallel":
+				t.Sequential = false
+			default:
+				return TudoTemplate{}, lineError(name, n, errors.New("expected sequential or parallel"))
+			}
+		case "outcome":
+			t.Outcome = &value
+		case "purpose":
+			t.Purpose = &value
+		default:
+			return TudoTemplate{}, lineError(name, n, errors.New("unknown key `"+key+"`"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return TudoTemplate{}, err
+	}
+	return t, nil
+}
+
+// dueOffset matches a due date offset such as `+3d` or `+2w`; any other
+// word is part of the task.
+var dueOffset = regexp.MustCompile(`^[+-]\d+[dw]$`)
+
+func parseTask(line string) (TudoTemplateTask, error) {
+	var task TudoTemplateTask
+	var words []string
+	for _, w := range strings.Fields(line) {
+		switch {
+		case len(w) > 1 && w[0] == '@':
+			context := w[1:]
+			task.Context = &context
+		case dueOffset.MatchString(w):
+			days, err := strconv.Atoi(w[:len(w)-1])
+			if err != nil {
+				return TudoTemplateTask{}, errors.New("invalid due offset `" + w + "`")
+			}
+			if w[len(w)-1] == 'w' {
+				days *= 7
+			}
+			task.DueOffset = &days
+		default:
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return TudoTemplateTask{}, errors.New("task without content")
+	}
+	task.Content = strings.Join(words, " ")
+	return task, nil
+}
+
+func lineError(name string, n int, err error) error {
+	return errors.New("template `" + name + "` line " + strconv.Itoa(n) + ": " + err.Error())
+}
@@ -12,21 +12,51 @@ type TudoWaiting struct {
 	Done       bool
 	CreatedAt  string
 	FinishedAt *string
+	// PersonID is who the item is waited on from, Delegate their name.
+	// DelegatedAt is when it was handed over, ExpectedBy when it is due
+	// back and FollowUp when to chase it.
+	PersonID    *uint32
+	Delegate    *string
+	DelegatedAt *string
+	ExpectedBy  *string
+	FollowUp    *string
+	// ProjectID and Context are kept from a delegated task, for the task
+	// that follows up on it.
+	ProjectID *uint32
+	Context   *string
 }
 
-func New(db *sql.DB, content string) error {
-	if _, err := db.Exec("INSERT INTO waiting (id, content, done, created_at, finished_at) VALUES (NULL, ?, 0, date(), NULL)", content); err != nil {
-		return err
+const waitingSelect = "SELECT id, content, done, created_at, finished_at, person_id, (SELECT name FROM people WHERE people.id = waiting.person_id), delegated_at, expected_by, follow_up, project_id, context"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWaiting(s scanner, w *TudoWaiting) error {
+	return s.Scan(&w.ID, &w.Content, &w.Done, &w.CreatedAt, &w.FinishedAt, &w.PersonID, &w.Delegate, &w.DelegatedAt, &w.ExpectedBy, &w.FollowUp, &w.ProjectID, &w.Context)
+}
+
+// New creates a waiting-for item, delegated today unless DelegatedAt is set.
+func New(db *sql.DB, w TudoWaiting) (uint32, error) {
+	res, err := db.Exec(`
+INSERT INTO waiting (id, content, done, created_at, finished_at, person_id, delegated_at, expected_by, follow_up, project_id, context)
+VALUES (NULL, ?, 0, date(), NULL, ?, COALESCE(?, date()), ?, ?, ?, ?)`, w.Content, w.PersonID, w.DelegatedAt, w.ExpectedBy, w.FollowUp, w.ProjectID, w.Context)
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
 }
 
 func IDExists(db *sql.DB, id uint32) (bool, error) {
-	row := db.QueryRow("SELECT id, content, done, created_at FROM waiting WHERE id = ? AND done = 0", id)
+	row := db.QueryRow("SELECT id FROM waiting WHERE id = ? AND done = 0", id)
 
 	var wID uint32
 	if err := row.Scan(&wID); errors.Is(err, sql.ErrNoRows) {
-		return false, err
+		return false, nil
 	} else if err != nil {
 		return false, err
 	}
@@ -46,8 +76,34 @@ func ContentExists(db *sql.DB, content string) (bool, uint32, error) {
 	return true, id, nil
 }
 
+func Get(db *sql.DB, id uint32) (TudoWaiting, error) {
+	row := db.QueryRow(waitingSelect+" FROM waiting WHERE id = ?", id)
+	var w TudoWaiting
+	if err := scanWaiting(row, &w); err != nil {
+		return TudoWaiting{}, err
+	}
+	return w, nil
+}
+
 func GetActive(db *sql.DB) ([]TudoWaiting, error) {
-	rows, err := db.Query("SELECT id, content, done, created_at FROM waiting WHERE done = 0")
+	return query(db, waitingSelect+" FROM waiting WHERE done = 0")
+}
+
+// GetForPerson returns the unfinished items waited on from a person.
+func GetForPerson(db *sql.DB, personID uint32) ([]TudoWaiting, error) {
+	return query(db, waitingSelect+" FROM waiting WHERE done = 0 AND person_id = ? ORDER BY id", personID)
+}
+
+// GetOverdue returns the unfinished items that need a nudge: their
+// follow-up date has come or they were expected back before today.
+func GetOverdue(db *sql.DB) ([]TudoWaiting, error) {
+	return query(db, waitingSelect+` FROM waiting
+WHERE done = 0 AND (follow_up <= date('now', 'localtime') OR expected_by < date('now', 'localtime'))
+ORDER BY MIN(COALESCE(follow_up, expected_by), COALESCE(expected_by, follow_up)), id`)
+}
+
+func query(db *sql.DB, query string, args ...any) ([]TudoWaiting, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return []TudoWaiting{}, err
 	}
@@ -56,7 +112,7 @@ func GetActive(db *sql.DB) ([]TudoWaiting, error) {
 	var waitList []TudoWaiting
 	for rows.Next() {
 		var w TudoWaiting
-		if err := rows.Scan(&w.ID, &w.Content, &w.Done, &w.CreatedAt); err != nil {
+		if err := scanWaiting(rows, &w); err != nil {
 			return []TudoWaiting{}, err
 		}
 		waitList = append(waitList, w)
@@ -65,6 +121,15 @@ func GetActive(db *sql.DB) ([]TudoWaiting, error) {
 	return waitList, nil
 }
 
+// Update overwrites the editable fields of the item with the given id.
+func Update(db *sql.DB, w TudoWaiting) error {
+	if _, err := db.Exec("UPDATE waiting SET content = ?, person_id = ?, delegated_at = ?, expected_by = ?, follow_up = ? WHERE id = ?",
+		w.Content, w.PersonID, w.DelegatedAt, w.ExpectedBy, w.FollowUp, w.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
 func Done(db *sql.DB, id uint32) error {
 	if _, err := db.Exec("UPDATE waiting SET done = 1, finished_at = date() WHERE id = ?", id); err != nil {
 		return err
@@ -73,7 +138,7 @@ func Done(db *sql.DB, id uint32) error {
 }
 
 func Review(db *sql.DB, thresh time.Time) (map[time.Time][]TudoWaiting, error) {
-	rows, err := db.Query("SELECT id, content, done, created_at, finished_at FROM waiting WHERE done = 1")
+	rows, err := db.Query(waitingSelect + " FROM waiting WHERE done = 1")
 	if err != nil {
 		return map[time.Time][]TudoWaiting{}, err
 	}
@@ -82,7 +147,7 @@ func Review(db *sql.DB, thresh time.Time) (map[time.Time][]TudoWaiting, error) {
 	finishedByDate := make(map[time.Time][]TudoWaiting)
 	for rows.Next() {
 		var w TudoWaiting
-		if err := rows.Scan(&w.ID, &w.Content, &w.Done, &w.CreatedAt, &w.FinishedAt); err != nil {
+		if err := scanWaiting(rows, &w); err != nil {
 			return map[time.Time][]TudoWaiting{}, err
 		}
 
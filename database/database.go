@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 
 	_ "modernc.org/sqlite"
 )
@@ -76,6 +77,170 @@ func Setup(dbFile string) error {
 	return nil
 }
 
+// migrations are applied in order on top of createTables. The number of
+// migrations already applied to a database is kept in PRAGMA user_version,
+// so new entries must only ever be appended.
+var migrations = []string{
+	`ALTER TABLE contexts ADD COLUMN parent_id INTEGER;`,
+	`
+CREATE TABLE IF NOT EXISTS context_schedules (
+  id INTEGER NOT NULL PRIMARY KEY,
+  context_id INTEGER NOT NULL,
+  weekdays TEXT NOT NULL,
+  start_time TEXT NOT NULL,
+  end_time TEXT NOT NULL
+);
+`,
+	`
+ALTER TABLE tasks ADD COLUMN estimate INTEGER;
+ALTER TABLE tasks ADD COLUMN energy TEXT;
+`,
+	`ALTER TABLE tasks ADD COLUMN priority TEXT;`,
+	`
+CREATE TABLE IF NOT EXISTS tags (
+  id INTEGER NOT NULL PRIMARY KEY,
+  content TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS item_tags (
+  tag_id INTEGER NOT NULL,
+  table_name TEXT NOT NULL,
+  row_id INTEGER NOT NULL,
+  PRIMARY KEY (tag_id, table_name, row_id)
+);
+`,
+	`
+CREATE TABLE IF NOT EXISTS notes (
+  id INTEGER NOT NULL PRIMARY KEY,
+  table_name TEXT NOT NULL,
+  row_id INTEGER NOT NULL,
+  content TEXT NOT NULL,
+  created_at TEXT NOT NULL
+);
+`,
+	`ALTER TABLE action_log ADD COLUMN action TEXT NOT NULL DEFAULT 'done';`,
+	`
+CREATE TABLE IF NOT EXISTS checklist (
+  id INTEGER NOT NULL PRIMARY KEY,
+  task_id INTEGER NOT NULL,
+  content TEXT NOT NULL,
+  done INTEGER NOT NULL,
+  created_at TEXT NOT NULL
+);
+`,
+	`
+CREATE TABLE IF NOT EXISTS task_dependencies (
+  task_id INTEGER NOT NULL,
+  blocked_by INTEGER NOT NULL,
+  PRIMARY KEY (task_id, blocked_by)
+);
+`,
+	`
+ALTER TABLE projects ADD COLUMN sequential INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN position INTEGER;
+UPDATE tasks SET position = id WHERE project_id IS NOT NULL;
+`,
+	`
+ALTER TABLE projects ADD COLUMN outcome TEXT;
+ALTER TABLE projects ADD COLUMN purpose TEXT;
+
+CREATE TABLE IF NOT EXISTS project_references (
+  id INTEGER NOT NULL PRIMARY KEY,
+  project_id INTEGER NOT NULL,
+  content TEXT NOT NULL,
+  created_at TEXT NOT NULL
+);
+`,
+	`
+CREATE TABLE IF NOT EXISTS areas (
+  id INTEGER NOT NULL PRIMARY KEY,
+  content TEXT NOT NULL UNIQUE,
+  horizon INTEGER NOT NULL DEFAULT 2,
+  parent_id INTEGER,
+  created_at TEXT NOT NULL
+);
+
+ALTER TABLE projects ADD COLUMN area_id INTEGER;
+`,
+	`ALTER TABLE projects ADD COLUMN parent_id INTEGER;`,
+	`ALTER TABLE projects ADD COLUMN archived_at TEXT;`,
+	`ALTER TABLE tasks ADD COLUMN cancelled INTEGER NOT NULL DEFAULT 0;`,
+	`
+ALTER TABLE waiting ADD COLUMN delegate TEXT;
+ALTER TABLE waiting ADD COLUMN delegated_at TEXT;
+ALTER TABLE waiting ADD COLUMN expected_by TEXT;
+ALTER TABLE waiting ADD COLUMN follow_up TEXT;
+`,
+	`
+CREATE TABLE IF NOT EXISTS people (
+  id INTEGER NOT NULL PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  created_at TEXT NOT NULL
+);
+
+ALTER TABLE tasks ADD COLUMN person_id INTEGER;
+ALTER TABLE waiting ADD COLUMN person_id INTEGER;
+
+INSERT OR IGNORE INTO people (name, created_at)
+SELECT DISTINCT delegate, date() FROM waiting WHERE delegate IS NOT NULL AND delegate != '';
+UPDATE waiting SET person_id = (SELECT id FROM people WHERE name = waiting.delegate);
+ALTER TABLE waiting DROP COLUMN delegate;
+`,
+	`
+ALTER TABLE waiting ADD COLUMN project_id INTEGER;
+ALTER TABLE waiting ADD COLUMN context TEXT;
+`,
+	`
+ALTER TABLE someday ADD COLUMN category TEXT;
+ALTER TABLE someday ADD COLUMN reviewed_at TEXT;
+`,
+	`
+CREATE TABLE IF NOT EXISTS reading (
+  id INTEGER NOT NULL PRIMARY KEY,
+  title TEXT NOT NULL,
+  url TEXT,
+  source TEXT,
+  minutes INTEGER,
+  done INTEGER NOT NULL,
+  read_at TEXT,
+  created_at TEXT NOT NULL
+);
+`,
+}
+
+func Migrate(dbFile string) error {
+	db, err := sql.Open("sqlite", dbFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(fmt.Sprint("PRAGMA user_version = ", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func Connect(dbFile string) (*sql.DB, error) {
 	db, err := sql.Open("sqlite", dbFile)
 	if err != nil {
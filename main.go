@@ -49,6 +49,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := database.Migrate(dbFile); err != nil {
+		fmt.Println("Could not migrate database :" + err.Error())
+		os.Exit(1)
+	}
+
 	args := os.Args
 	args = args[1:]
 	plaintext.ParseArgs(dbFile, args)